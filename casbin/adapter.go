@@ -0,0 +1,136 @@
+package casbin
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Anniext/Arkitektur/cache"
+	"github.com/Anniext/Arkitektur/data"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	fileadapter "github.com/casbin/casbin/v2/persist/file-adapter"
+	xormadapter "github.com/casbin/xorm-adapter/v3"
+)
+
+const (
+	AdapterXorm   = "xorm"   // 数据库适配器, 依赖数据模块
+	AdapterFile   = "file"   // csv文件适配器
+	AdapterRedis  = "redis"  // redis适配器, 依赖缓存模块
+	AdapterMemory = "memory" // 内存适配器, 用于测试
+
+	DefaultRedisKey = "casbin:policy"
+)
+
+// DefaultRBACModel 内置的RBAC模型, ModelText与ModelPath都为空时使用
+//
+//go:embed rbac_model.conf
+var DefaultRBACModel string
+
+// DefaultRBACWithDomainsModel 内置的多租户RBAC模型, 请求为 sub, dom, obj, act
+//
+//go:embed rbac_with_domains_model.conf
+var DefaultRBACWithDomainsModel string
+
+// NewModel function    加载模型, 优先使用模型文本
+func NewModel(cnf *CasbinConfig) (model.Model, error) {
+	if cnf.ModelText != "" {
+		return model.NewModelFromString(cnf.ModelText)
+	}
+
+	if cnf.ModelPath != "" {
+		return model.NewModelFromFile(cnf.ModelPath)
+	}
+
+	if cnf.Domain {
+		return model.NewModelFromString(DefaultRBACWithDomainsModel)
+	}
+
+	return model.NewModelFromString(DefaultRBACModel)
+}
+
+// NewAdapter function    根据配置创建适配器
+func NewAdapter(cnf *CasbinConfig) (persist.Adapter, error) {
+	if cnf.CustomAdapter != nil {
+		return cnf.CustomAdapter, nil
+	}
+
+	switch cnf.Adapter {
+	case AdapterXorm, "":
+		engine := data.GetDB()
+		if engine == nil {
+			return nil, errors.New("casbin xorm adapter: database is not initialized")
+		}
+		return xormadapter.NewAdapterByEngine(engine)
+	case AdapterFile:
+		if cnf.PolicyPath == "" {
+			return nil, errors.New("casbin file adapter: policy path is empty")
+		}
+		return fileadapter.NewAdapter(cnf.PolicyPath), nil
+	case AdapterRedis:
+		client := cache.GetDefaultRedis()
+		if client == nil {
+			return nil, errors.New("casbin redis adapter: redis is not initialized")
+		}
+		key := cnf.RedisKey
+		if key == "" {
+			key = DefaultRedisKey
+		}
+		return NewRedisAdapter(client, key), nil
+	case AdapterMemory:
+		return NewMemoryAdapter(), nil
+	default:
+		return nil, fmt.Errorf("casbin: unknown adapter %q", cnf.Adapter)
+	}
+}
+
+// policyLine function    拼接完整策略行, 首列为ptype
+func policyLine(ptype string, rule []string) []string {
+	return append([]string{ptype}, rule...)
+}
+
+// matchFilter function    判断策略行是否匹配过滤条件, 空字符串表示不限制
+func matchFilter(line []string, ptype string, fieldIndex int, fieldValues ...string) bool {
+	if len(line) == 0 || line[0] != ptype {
+		return false
+	}
+
+	rule := line[1:]
+	for i, value := range fieldValues {
+		if value == "" {
+			continue
+		}
+		if fieldIndex+i >= len(rule) || rule[fieldIndex+i] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// modelLines function    导出模型中的全部策略行
+func modelLines(m model.Model) [][]string {
+	var lines [][]string
+	for _, sec := range []string{"p", "g"} {
+		for ptype, ast := range m[sec] {
+			for _, rule := range ast.Policy {
+				lines = append(lines, policyLine(ptype, rule))
+			}
+		}
+	}
+
+	return lines
+}
+
+// removeLine function    删除一条完全相同的策略行
+func removeLine(lines [][]string, line []string) ([][]string, bool) {
+	idx := slices.IndexFunc(lines, func(l []string) bool {
+		return slices.Equal(l, line)
+	})
+	if idx < 0 {
+		return lines, false
+	}
+
+	return slices.Delete(lines, idx, idx+1), true
+}
@@ -1,7 +1,20 @@
 package casbin
 
+import (
+	"github.com/Anniext/Arkitektur/system/config"
+	"github.com/casbin/casbin/v2/persist"
+)
+
 type CasbinConfig struct {
-	ModelPath string
+	ModelPath      string
+	ModelText      string          // 模型文本, 优先于ModelPath, 可配合go:embed使用
+	Domain         bool            // 未指定模型时使用内置的多租户RBAC模型
+	Adapter        string          // 适配器类型: xorm、file、redis、memory
+	PolicyPath     string          // file适配器的csv策略文件
+	RedisKey       string          // redis适配器的存储key
+	CustomAdapter  persist.Adapter // 自定义适配器, 优先于Adapter
+	Watcher        string          // 策略同步方式: redis、mqtt, 为空不同步
+	WatcherChannel string          // 策略同步的频道或主题
 }
 type Option func(*CasbinConfig)
 
@@ -11,6 +24,61 @@ func WithModelPathOption(modelPath string) Option {
 	}
 }
 
+func WithModelTextOption(modelText string) Option {
+	return func(c *CasbinConfig) {
+		c.ModelText = modelText
+	}
+}
+
+func WithDomainOption(domain bool) Option {
+	return func(c *CasbinConfig) {
+		c.Domain = domain
+	}
+}
+
+func WithAdapterOption(adapter string) Option {
+	return func(c *CasbinConfig) {
+		c.Adapter = adapter
+	}
+}
+
+func WithPolicyPathOption(policyPath string) Option {
+	return func(c *CasbinConfig) {
+		c.PolicyPath = policyPath
+	}
+}
+
+func WithRedisKeyOption(redisKey string) Option {
+	return func(c *CasbinConfig) {
+		c.RedisKey = redisKey
+	}
+}
+
+func WithCustomAdapterOption(adapter persist.Adapter) Option {
+	return func(c *CasbinConfig) {
+		c.CustomAdapter = adapter
+	}
+}
+
+func WithWatcherOption(watcher, channel string) Option {
+	return func(c *CasbinConfig) {
+		c.Watcher = watcher
+		c.WatcherChannel = channel
+	}
+}
+
+// OptionsFromInfo function    将服务配置中的casbin配置转换为Option
+func OptionsFromInfo(info config.CasbinInfo) []Option {
+	return []Option{
+		WithModelPathOption(info.ModelPath),
+		WithDomainOption(info.Domain),
+		WithAdapterOption(info.Adapter),
+		WithPolicyPathOption(info.PolicyPath),
+		WithRedisKeyOption(info.RedisKey),
+		WithWatcherOption(info.Watcher, info.WatcherChannel),
+	}
+}
+
 func NewCacheOption(options ...Option) {
 	defaultCasbinConfig = &CasbinConfig{}
 	for _, option := range options {
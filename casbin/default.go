@@ -1,22 +1,34 @@
 package casbin
 
 import (
-	"github.com/Anniext/Arkitektur/data"
+	"github.com/Anniext/Arkitektur/system/config"
 	"github.com/casbin/casbin/v2"
-	xormadapter "github.com/casbin/xorm-adapter/v3"
 )
 
 var defaultCasbin *casbin.SyncedEnforcer
 
-// InitCasbin TODO 依赖数据模块， 目前只支持xorm
-// InitCasbin 初始化casbin
+// InitCasbin 初始化casbin, 没有调用NewCacheOption时读取服务配置中的casbin配置
 func InitCasbin() error {
 	cnf := GetDefaultCasbinConfig()
+	if cnf == nil && config.GetServerConfig() != nil {
+		NewCacheOption(OptionsFromInfo(config.GetCasbinInfo())...)
+		cnf = GetDefaultCasbinConfig()
+	}
+	if cnf == nil {
+		cnf = &CasbinConfig{}
+	}
 
-	var err error
-	modePath := cnf.ModelPath
-	a, _ := xormadapter.NewAdapterByEngine(data.GetDB())
-	defaultCasbin, err = casbin.NewSyncedEnforcer(modePath, a)
+	m, err := NewModel(cnf)
+	if err != nil {
+		return err
+	}
+
+	a, err := NewAdapter(cnf)
+	if err != nil {
+		return err
+	}
+
+	defaultCasbin, err = casbin.NewSyncedEnforcer(m, a)
 	if err != nil {
 		return err
 	}
@@ -26,9 +38,32 @@ func InitCasbin() error {
 		return err
 	}
 
+	if cnf.Watcher != "" {
+		return initWatcher(cnf)
+	}
+
 	return nil
 }
 
+// initWatcher 初始化策略同步
+func initWatcher(cnf *CasbinConfig) error {
+	transport, err := NewWatcherTransport(cnf)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := NewWatcher(transport)
+	if err != nil {
+		return err
+	}
+
+	if err = defaultCasbin.SetWatcher(watcher); err != nil {
+		return err
+	}
+
+	return watcher.SetUpdateCallback(NewIncrementalCallback(defaultCasbin))
+}
+
 func GetDefaultCasbin() *casbin.SyncedEnforcer {
 	return defaultCasbin
 }
@@ -0,0 +1,40 @@
+package casbin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Anniext/Arkitektur/system/config"
+)
+
+func TestInitCasbinFromServerConfig(t *testing.T) {
+	dir := t.TempDir()
+	yaml := "casbin:\n  domain: true\n  adapter: memory\n  watcherChannel: policy\n"
+	if err := os.WriteFile(filepath.Join(dir, "app-test.yaml"), []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pwd, _ := os.Getwd()
+	rel, err := filepath.Rel(pwd, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = config.InitSystemConfig("app", "test", rel); err != nil {
+		t.Fatal(err)
+	}
+
+	defaultCasbinConfig = nil
+	t.Cleanup(func() { defaultCasbinConfig, defaultCasbin = nil, nil })
+
+	if err = InitCasbin(); err != nil {
+		t.Fatal(err)
+	}
+
+	cnf := GetDefaultCasbinConfig()
+	if cnf.Adapter != AdapterMemory || !cnf.Domain || cnf.WatcherChannel != "policy" {
+		t.Errorf("config = %+v", cnf)
+	}
+	if !IsDomainModel(GetDefaultCasbin()) {
+		t.Error("domain model not loaded from casbin.domain")
+	}
+}
@@ -0,0 +1,87 @@
+package casbin
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+)
+
+// MemoryAdapter struct    内存适配器, 进程退出后策略丢失
+type MemoryAdapter struct {
+	mu    sync.RWMutex
+	lines [][]string
+}
+
+// NewMemoryAdapter function    新建内存适配器, 可传入初始策略行
+func NewMemoryAdapter(lines ...[]string) *MemoryAdapter {
+	return &MemoryAdapter{lines: lines}
+}
+
+// LoadPolicy method    加载全部策略
+func (a *MemoryAdapter) LoadPolicy(m model.Model) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, line := range a.lines {
+		if err := persist.LoadPolicyArray(line, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SavePolicy method    保存全部策略
+func (a *MemoryAdapter) SavePolicy(m model.Model) error {
+	lines := modelLines(m)
+
+	a.mu.Lock()
+	a.lines = lines
+	a.mu.Unlock()
+	return nil
+}
+
+// AddPolicy method    增加策略
+func (a *MemoryAdapter) AddPolicy(sec string, ptype string, rule []string) error {
+	return a.AddPolicies(sec, ptype, [][]string{rule})
+}
+
+// AddPolicies method    批量增加策略
+func (a *MemoryAdapter) AddPolicies(sec string, ptype string, rules [][]string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, rule := range rules {
+		a.lines = append(a.lines, policyLine(ptype, rule))
+	}
+	return nil
+}
+
+// RemovePolicy method    删除策略
+func (a *MemoryAdapter) RemovePolicy(sec string, ptype string, rule []string) error {
+	return a.RemovePolicies(sec, ptype, [][]string{rule})
+}
+
+// RemovePolicies method    批量删除策略
+func (a *MemoryAdapter) RemovePolicies(sec string, ptype string, rules [][]string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, rule := range rules {
+		a.lines, _ = removeLine(a.lines, policyLine(ptype, rule))
+	}
+	return nil
+}
+
+// RemoveFilteredPolicy method    按条件删除策略
+func (a *MemoryAdapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.lines = slices.DeleteFunc(a.lines, func(line []string) bool {
+		return matchFilter(line, ptype, fieldIndex, fieldValues...)
+	})
+	return nil
+}
@@ -0,0 +1,144 @@
+package casbin
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisAdapter struct    redis适配器, 策略行以json数组形式存放在list中
+type RedisAdapter struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisAdapter function    新建redis适配器
+func NewRedisAdapter(client *redis.Client, key string) *RedisAdapter {
+	return &RedisAdapter{client: client, key: key}
+}
+
+// LoadPolicy method    加载全部策略
+func (a *RedisAdapter) LoadPolicy(m model.Model) error {
+	values, err := a.client.LRange(context.Background(), a.key, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, value := range values {
+		var line []string
+		if err = json.Unmarshal([]byte(value), &line); err != nil {
+			return err
+		}
+		if err = persist.LoadPolicyArray(line, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SavePolicy method    保存全部策略
+func (a *RedisAdapter) SavePolicy(m model.Model) error {
+	values, err := encodeLines(modelLines(m))
+	if err != nil {
+		return err
+	}
+
+	_, err = a.client.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Del(context.Background(), a.key)
+		if len(values) != 0 {
+			pipe.RPush(context.Background(), a.key, values...)
+		}
+		return nil
+	})
+	return err
+}
+
+// AddPolicy method    增加策略
+func (a *RedisAdapter) AddPolicy(sec string, ptype string, rule []string) error {
+	return a.AddPolicies(sec, ptype, [][]string{rule})
+}
+
+// AddPolicies method    批量增加策略
+func (a *RedisAdapter) AddPolicies(sec string, ptype string, rules [][]string) error {
+	lines := make([][]string, 0, len(rules))
+	for _, rule := range rules {
+		lines = append(lines, policyLine(ptype, rule))
+	}
+
+	values, err := encodeLines(lines)
+	if err != nil || len(values) == 0 {
+		return err
+	}
+
+	return a.client.RPush(context.Background(), a.key, values...).Err()
+}
+
+// RemovePolicy method    删除策略
+func (a *RedisAdapter) RemovePolicy(sec string, ptype string, rule []string) error {
+	return a.RemovePolicies(sec, ptype, [][]string{rule})
+}
+
+// RemovePolicies method    批量删除策略
+func (a *RedisAdapter) RemovePolicies(sec string, ptype string, rules [][]string) error {
+	lines := make([][]string, 0, len(rules))
+	for _, rule := range rules {
+		lines = append(lines, policyLine(ptype, rule))
+	}
+
+	return a.removeLines(lines)
+}
+
+// RemoveFilteredPolicy method    按条件删除策略
+func (a *RedisAdapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
+	values, err := a.client.LRange(context.Background(), a.key, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+
+	var lines [][]string
+	for _, value := range values {
+		var line []string
+		if err = json.Unmarshal([]byte(value), &line); err != nil {
+			return err
+		}
+		if matchFilter(line, ptype, fieldIndex, fieldValues...) {
+			lines = append(lines, line)
+		}
+	}
+
+	return a.removeLines(lines)
+}
+
+// removeLines method    逐条删除策略行
+func (a *RedisAdapter) removeLines(lines [][]string) error {
+	values, err := encodeLines(lines)
+	if err != nil || len(values) == 0 {
+		return err
+	}
+
+	_, err = a.client.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		for _, value := range values {
+			pipe.LRem(context.Background(), a.key, 1, value)
+		}
+		return nil
+	})
+	return err
+}
+
+// encodeLines function    将策略行编码为json字符串
+func encodeLines(lines [][]string) ([]any, error) {
+	values := make([]any, 0, len(lines))
+	for _, line := range lines {
+		value, err := json.Marshal(line)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, string(value))
+	}
+
+	return values, nil
+}
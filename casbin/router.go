@@ -0,0 +1,437 @@
+package casbin
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/Anniext/Arkitektur/common"
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	FormatJson = "json"
+	FormatCsv  = "csv"
+)
+
+// ErrPolicyInvalid 导入的策略行与模型定义不符
+var ErrPolicyInvalid = errors.New("casbin: invalid policy line")
+
+// PolicyReq struct    批量增删策略请求, 规则字段顺序与模型定义一致
+type PolicyReq struct {
+	Ptype string     `json:"ptype"` // 为空时策略默认p, 角色继承默认g
+	Rules [][]string `json:"rules"`
+}
+
+// ExplainReq struct    权限推演请求, 字段顺序与request_definition一致
+type ExplainReq struct {
+	Request []string `json:"request"`
+}
+
+// ExplainResp struct    权限推演结果
+type ExplainResp struct {
+	Allowed bool     `json:"allowed"`
+	Matched []string `json:"matched"` // 命中的策略, 拒绝时为空
+	Roles   []string `json:"roles"`   // 请求主体继承的全部角色
+	Domain  string   `json:"domain"`  // 多租户模型下的域
+}
+
+// RegisterPolicyRouter function    注册策略管理路由, 由业务决定挂载的路由组与鉴权中间件
+func RegisterPolicyRouter(group *gin.RouterGroup) {
+	group.GET("/policies", listPolicies("p"))
+	group.POST("/policies", addPolicies("p"))
+	group.DELETE("/policies", removePolicies("p"))
+	group.GET("/roles", listPolicies("g"))
+	group.POST("/roles", addPolicies("g"))
+	group.DELETE("/roles", removePolicies("g"))
+	group.GET("/export", exportPolicies)
+	group.POST("/import", importPolicies)
+	group.POST("/explain", explainPolicy)
+}
+
+// enforcer function    获取已启用的enforcer
+func enforcer(ctx *gin.Context) *casbin.SyncedEnforcer {
+	e := GetDefaultCasbin()
+	if e == nil {
+		api := &common.CodeApi{}
+		api.Fail(ctx, code.ErrCodeCasbinNotActiveYet)
+	}
+	return e
+}
+
+// IsDomainModel function    请求定义为 sub, dom, obj, act 时视为多租户模型
+func IsDomainModel(e *casbin.SyncedEnforcer) bool {
+	assertion, ok := e.GetModel()["r"]["r"]
+	return ok && len(assertion.Tokens) == 4
+}
+
+// listPolicies function    查询策略, 支持v0~v5按字段过滤
+func listPolicies(defaultPtype string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		e := enforcer(ctx)
+		if e == nil {
+			return
+		}
+
+		ptype := ctx.DefaultQuery("ptype", defaultPtype)
+		fieldIndex, fieldValues := -1, make([]string, 0, 6)
+		for i := 0; i < 6; i++ {
+			value := ctx.Query("v" + strconv.Itoa(i))
+			if value != "" && fieldIndex == -1 {
+				fieldIndex = i
+			}
+			if fieldIndex != -1 {
+				fieldValues = append(fieldValues, value)
+			}
+		}
+		if fieldIndex == -1 {
+			fieldIndex = 0
+		}
+
+		var rules [][]string
+		var err error
+		if strings.HasPrefix(ptype, "g") {
+			rules, err = e.GetFilteredNamedGroupingPolicy(ptype, fieldIndex, fieldValues...)
+		} else {
+			rules, err = e.GetFilteredNamedPolicy(ptype, fieldIndex, fieldValues...)
+		}
+
+		api := &common.CodeApi{}
+		if err != nil {
+			api.Fail(ctx, code.ErrCodeCasbinPolicyInvalid)
+			return
+		}
+		api.Success(ctx, rules)
+	}
+}
+
+// bindPolicyReq function    解析并校验批量策略请求
+func bindPolicyReq(ctx *gin.Context, defaultPtype string) (*PolicyReq, bool) {
+	req := &PolicyReq{}
+	api := &common.CodeApi{}
+	if err := ctx.ShouldBindJSON(req); err != nil || len(req.Rules) == 0 {
+		api.Fail(ctx, code.ErrCodeInvalidParams)
+		return nil, false
+	}
+
+	if req.Ptype == "" {
+		req.Ptype = defaultPtype
+	}
+	if !strings.HasPrefix(req.Ptype, defaultPtype) {
+		api.Fail(ctx, code.ErrCodeCasbinPolicyInvalid)
+		return nil, false
+	}
+
+	return req, true
+}
+
+// addPolicies function    批量增加策略或角色继承
+//
+// 全有或全无: 任意一条规则已存在时整批都不增加, 返回ErrCodeCasbinIdenticalAdditionFailed
+func addPolicies(defaultPtype string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		e := enforcer(ctx)
+		if e == nil {
+			return
+		}
+
+		req, ok := bindPolicyReq(ctx, defaultPtype)
+		if !ok {
+			return
+		}
+
+		var err error
+		if defaultPtype == "g" {
+			ok, err = e.AddNamedGroupingPolicies(req.Ptype, req.Rules)
+		} else {
+			ok, err = e.AddNamedPolicies(req.Ptype, req.Rules)
+		}
+
+		api := &common.CodeApi{}
+		if err != nil {
+			api.Fail(ctx, code.ErrCodeCasbinPolicyInvalid)
+			return
+		}
+		if !ok {
+			api.Fail(ctx, code.ErrCodeCasbinIdenticalAdditionFailed)
+			return
+		}
+		api.Success(ctx, nil)
+	}
+}
+
+// removePolicies function    批量删除策略或角色继承
+func removePolicies(defaultPtype string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		e := enforcer(ctx)
+		if e == nil {
+			return
+		}
+
+		req, ok := bindPolicyReq(ctx, defaultPtype)
+		if !ok {
+			return
+		}
+
+		var err error
+		if defaultPtype == "g" {
+			ok, err = e.RemoveNamedGroupingPolicies(req.Ptype, req.Rules)
+		} else {
+			ok, err = e.RemoveNamedPolicies(req.Ptype, req.Rules)
+		}
+
+		api := &common.CodeApi{}
+		if err != nil || !ok {
+			api.Fail(ctx, code.ErrCodeDeleteCasbinGlobalErr)
+			return
+		}
+		api.Success(ctx, nil)
+	}
+}
+
+// ExportPolicies function    导出全部策略与角色继承, 每行首列为ptype
+func ExportPolicies(e *casbin.SyncedEnforcer) [][]string {
+	lines := make([][]string, 0)
+	for _, sec := range []string{"p", "g"} {
+		for ptype, assertion := range e.GetModel()[sec] {
+			for _, rule := range assertion.Policy {
+				lines = append(lines, append([]string{ptype}, rule...))
+			}
+		}
+	}
+	return lines
+}
+
+// ImportPolicies function    导入策略行, 返回实际新增的规则数
+//
+// 导入前先在模型副本上校验全部策略行, 任意一行非法时不做任何修改并返回ErrPolicyInvalid;
+// replace为true时替换全部策略并通过适配器整体保存, 保存失败时从适配器重新加载;
+// 否则跳过已存在的规则, 只增加新规则
+func ImportPolicies(e *casbin.SyncedEnforcer, lines [][]string, replace bool) (int, error) {
+	if replace {
+		lock := e.GetLock()
+		lock.Lock()
+		defer lock.Unlock()
+
+		validated, err := validatePolicyLines(e.GetModel(), lines)
+		if err != nil {
+			return 0, err
+		}
+
+		e.Enforcer.ClearPolicy()
+		for _, line := range lines {
+			_ = persist.LoadPolicyArray(line, e.GetModel())
+		}
+		if err = e.Enforcer.BuildRoleLinks(); err == nil {
+			err = e.Enforcer.SavePolicy()
+		}
+		if err != nil {
+			_ = e.Enforcer.LoadPolicy()
+			return 0, err
+		}
+		return validated, nil
+	}
+
+	if _, err := validatePolicyLines(e.GetModel(), lines); err != nil {
+		return 0, err
+	}
+
+	grouped := make(map[string][][]string)
+	seen := make(map[string]struct{}, len(lines))
+	for _, line := range lines {
+		ptype, rule := line[0], line[1:]
+		key := strings.Join(line, ",")
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		var exists bool
+		if strings.HasPrefix(ptype, "g") {
+			exists, _ = e.HasNamedGroupingPolicy(ptype, rule)
+		} else {
+			exists, _ = e.HasNamedPolicy(ptype, rule)
+		}
+		if !exists {
+			grouped[ptype] = append(grouped[ptype], rule)
+		}
+	}
+
+	added := 0
+	for ptype, rules := range grouped {
+		var err error
+		if strings.HasPrefix(ptype, "g") {
+			_, err = e.AddNamedGroupingPoliciesEx(ptype, rules)
+		} else {
+			_, err = e.AddNamedPoliciesEx(ptype, rules)
+		}
+		if err != nil {
+			return added, err
+		}
+		added += len(rules)
+	}
+
+	return added, nil
+}
+
+// validatePolicyLines function    在清空策略的模型副本上加载策略行, 返回去重后的规则数
+func validatePolicyLines(m model.Model, lines [][]string) (int, error) {
+	validated := m.Copy()
+	validated.ClearPolicy()
+
+	for idx, line := range lines {
+		if len(line) < 2 || line[0] == "" {
+			return 0, fmt.Errorf("%w: line %d is empty", ErrPolicyInvalid, idx+1)
+		}
+		if err := persist.LoadPolicyArray(line, validated); err != nil {
+			return 0, fmt.Errorf("%w: line %d: %v", ErrPolicyInvalid, idx+1, err)
+		}
+	}
+
+	count := 0
+	for _, sec := range []string{"p", "g"} {
+		for _, assertion := range validated[sec] {
+			count += len(assertion.Policy)
+		}
+	}
+	return count, nil
+}
+
+// exportPolicies function    导出策略, format=csv时返回与file适配器兼容的csv文本
+func exportPolicies(ctx *gin.Context) {
+	e := enforcer(ctx)
+	if e == nil {
+		return
+	}
+
+	lines := ExportPolicies(e)
+	if ctx.DefaultQuery("format", FormatJson) != FormatCsv {
+		api := &common.CodeApi{}
+		api.Success(ctx, lines)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	writer := csv.NewWriter(buf)
+	_ = writer.WriteAll(lines)
+	ctx.Header("Content-Disposition", `attachment; filename="policy.csv"`)
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// importPolicies function    导入策略, 请求体为csv文本或json二维数组, replace=true时替换全部策略
+func importPolicies(ctx *gin.Context) {
+	e := enforcer(ctx)
+	if e == nil {
+		return
+	}
+
+	api := &common.CodeApi{}
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		api.Fail(ctx, code.ErrCodeInvalidParams)
+		return
+	}
+
+	format := ctx.Query("format")
+	if format == "" {
+		format = FormatJson
+		if strings.Contains(ctx.ContentType(), FormatCsv) {
+			format = FormatCsv
+		}
+	}
+
+	lines, err := decodePolicyLines(body, format)
+	if err != nil {
+		api.Fail(ctx, code.ErrCodeCasbinPolicyInvalid)
+		return
+	}
+
+	added, err := ImportPolicies(e, lines, ctx.Query("replace") == "true")
+	if errors.Is(err, ErrPolicyInvalid) {
+		api.Fail(ctx, code.ErrCodeCasbinPolicyInvalid)
+		return
+	}
+	if err != nil {
+		api.Fail(ctx, code.ErrCodeCasbinImportErr)
+		return
+	}
+	api.Success(ctx, added)
+}
+
+// decodePolicyLines function    解析导入内容, 跳过空行与#注释
+func decodePolicyLines(body []byte, format string) ([][]string, error) {
+	var records [][]string
+	switch format {
+	case FormatCsv:
+		reader := csv.NewReader(bytes.NewReader(body))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		reader.Comment = '#'
+		var err error
+		if records, err = reader.ReadAll(); err != nil {
+			return nil, err
+		}
+	case FormatJson:
+		if err := json.Unmarshal(body, &records); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("casbin: unknown format " + format)
+	}
+
+	lines := make([][]string, 0, len(records))
+	for _, record := range records {
+		if len(record) < 2 || record[0] == "" {
+			continue
+		}
+		lines = append(lines, record)
+	}
+
+	return lines, nil
+}
+
+// explainPolicy function    推演请求是否放行, 返回命中策略与主体继承的角色, 不产生任何修改
+func explainPolicy(ctx *gin.Context) {
+	e := enforcer(ctx)
+	if e == nil {
+		return
+	}
+
+	api := &common.CodeApi{}
+	req := &ExplainReq{}
+	if err := ctx.ShouldBindJSON(req); err != nil || len(req.Request) == 0 {
+		api.Fail(ctx, code.ErrCodeInvalidParams)
+		return
+	}
+
+	request := make([]any, 0, len(req.Request))
+	for _, v := range req.Request {
+		request = append(request, v)
+	}
+
+	allowed, matched, err := e.EnforceEx(request...)
+	if err != nil {
+		api.Fail(ctx, code.ErrCodeCasbinPolicyInvalid)
+		return
+	}
+
+	resp := &ExplainResp{Allowed: allowed, Matched: matched}
+	if IsDomainModel(e) && len(req.Request) > 1 {
+		resp.Domain = req.Request[1]
+		resp.Roles, _ = e.GetImplicitRolesForUser(req.Request[0], resp.Domain)
+	} else {
+		resp.Roles, _ = e.GetImplicitRolesForUser(req.Request[0])
+	}
+
+	api.Success(ctx, resp)
+}
@@ -0,0 +1,185 @@
+package casbin
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+)
+
+// failSaveAdapter struct    SavePolicy总是失败的内存适配器
+type failSaveAdapter struct {
+	*MemoryAdapter
+}
+
+func (a *failSaveAdapter) SavePolicy(model.Model) error {
+	return errors.New("save failed")
+}
+
+func newTestEnforcer(t *testing.T, adapter *MemoryAdapter) *casbin.SyncedEnforcer {
+	t.Helper()
+
+	m, err := model.NewModelFromString(DefaultRBACModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := casbin.NewSyncedEnforcer(m, adapter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func sortedLines(lines [][]string) [][]string {
+	sort.Slice(lines, func(i, j int) bool {
+		return strings.Join(lines[i], ",") < strings.Join(lines[j], ",")
+	})
+	return lines
+}
+
+var initialLines = [][]string{
+	{"p", "admin", "/api/*", "*"},
+	{"g", "alice", "admin"},
+}
+
+func TestImportPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   [][]string
+		replace bool
+		added   int
+		invalid bool
+		want    [][]string
+	}{
+		{
+			name:  "merge skips existing rules",
+			lines: [][]string{{"p", "admin", "/api/*", "*"}, {"p", "user", "/api/user", "GET"}, {"g", "bob", "user"}},
+			added: 2,
+			want: [][]string{
+				{"g", "alice", "admin"}, {"g", "bob", "user"},
+				{"p", "admin", "/api/*", "*"}, {"p", "user", "/api/user", "GET"},
+			},
+		},
+		{
+			name:  "merge counts duplicated lines once",
+			lines: [][]string{{"p", "user", "/api/user", "GET"}, {"p", "user", "/api/user", "GET"}},
+			added: 1,
+			want: [][]string{
+				{"g", "alice", "admin"},
+				{"p", "admin", "/api/*", "*"}, {"p", "user", "/api/user", "GET"},
+			},
+		},
+		{
+			name:    "merge rejects invalid line without changes",
+			lines:   [][]string{{"p", "user", "/api/user", "GET"}, {"p", "user", "/api/user"}},
+			invalid: true,
+			want:    initialLines,
+		},
+		{
+			name:    "replace swaps all rules",
+			lines:   [][]string{{"p", "user", "/api/user", "GET"}, {"g", "bob", "user"}},
+			replace: true,
+			added:   2,
+			want:    [][]string{{"g", "bob", "user"}, {"p", "user", "/api/user", "GET"}},
+		},
+		{
+			name:    "replace rejects unknown ptype without changes",
+			lines:   [][]string{{"p", "user", "/api/user", "GET"}, {"p9", "user", "/api/user", "GET"}},
+			replace: true,
+			invalid: true,
+			want:    initialLines,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := NewMemoryAdapter(initialLines...)
+			e := newTestEnforcer(t, adapter)
+
+			added, err := ImportPolicies(e, tt.lines, tt.replace)
+			if tt.invalid {
+				if !errors.Is(err, ErrPolicyInvalid) {
+					t.Fatalf("err = %v, want ErrPolicyInvalid", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if added != tt.added {
+				t.Errorf("added = %d, want %d", added, tt.added)
+			}
+
+			want := sortedLines(append([][]string(nil), tt.want...))
+			if got := sortedLines(ExportPolicies(e)); !reflect.DeepEqual(got, want) {
+				t.Errorf("enforcer policies = %v, want %v", got, want)
+			}
+			if got := sortedLines(adapter.lines); !reflect.DeepEqual(got, want) {
+				t.Errorf("adapter policies = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestImportPoliciesReplaceSaveFailure(t *testing.T) {
+	adapter := &failSaveAdapter{MemoryAdapter: NewMemoryAdapter(initialLines...)}
+	m, err := model.NewModelFromString(DefaultRBACModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := casbin.NewSyncedEnforcer(m, adapter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = ImportPolicies(e, [][]string{{"p", "user", "/api/user", "GET"}}, true); err == nil {
+		t.Fatal("expected save error")
+	}
+
+	want := sortedLines(append([][]string(nil), initialLines...))
+	if got := sortedLines(ExportPolicies(e)); !reflect.DeepEqual(got, want) {
+		t.Errorf("policies after failed replace = %v, want %v", got, want)
+	}
+	if ok, _ := e.Enforce("alice", "/api/user", "GET"); !ok {
+		t.Error("role links not restored after failed replace")
+	}
+}
+
+func TestDecodePolicyLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		format  string
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name:   "csv skips comments and blank lines",
+			body:   "# header\np, admin, /api/*, *\n\ng, alice, admin\n",
+			format: FormatCsv,
+			want:   [][]string{{"p", "admin", "/api/*", "*"}, {"g", "alice", "admin"}},
+		},
+		{
+			name:   "json skips short rows",
+			body:   `[["p","admin","/api/*","*"],["p"],["","x"]]`,
+			format: FormatJson,
+			want:   [][]string{{"p", "admin", "/api/*", "*"}},
+		},
+		{name: "bad json", body: `{`, format: FormatJson, wantErr: true},
+		{name: "unknown format", body: ``, format: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodePolicyLines([]byte(tt.body), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lines = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
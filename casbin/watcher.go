@@ -0,0 +1,248 @@
+package casbin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Anniext/Arkitektur/system/log"
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+)
+
+const (
+	WatcherRedis = "redis" // redis发布订阅
+	WatcherMqtt  = "mqtt"  // 项目自身的mqtt客户端
+
+	DefaultWatcherChannel = "casbin/watcher"
+)
+
+// ErrWatcherDrift 本副本的策略与广播方不一致, 增量变更没有生效
+var ErrWatcherDrift = errors.New("casbin watcher: policy drifted from the broadcasting replica")
+
+// 广播的更新类型
+const (
+	updateAll                     = "Update"
+	updateForAddPolicy            = "UpdateForAddPolicy"
+	updateForRemovePolicy         = "UpdateForRemovePolicy"
+	updateForRemoveFilteredPolicy = "UpdateForRemoveFilteredPolicy"
+	updateForSavePolicy           = "UpdateForSavePolicy"
+	updateForAddPolicies          = "UpdateForAddPolicies"
+	updateForRemovePolicies       = "UpdateForRemovePolicies"
+	updateForUpdatePolicy         = "UpdateForUpdatePolicy"
+	updateForUpdatePolicies       = "UpdateForUpdatePolicies"
+)
+
+// WatcherTransport interface    watcher的广播通道
+type WatcherTransport interface {
+	Publish(payload []byte) error
+	Subscribe(callback func(payload []byte)) error
+	Close() error
+}
+
+// watcherMessage struct    广播消息
+type watcherMessage struct {
+	Method      string     `json:"method"`
+	ID          string     `json:"id"`
+	Sec         string     `json:"sec,omitempty"`
+	Ptype       string     `json:"ptype,omitempty"`
+	Rules       [][]string `json:"rules,omitempty"`
+	NewRules    [][]string `json:"newRules,omitempty"`
+	FieldIndex  int        `json:"fieldIndex,omitempty"`
+	FieldValues []string   `json:"fieldValues,omitempty"`
+}
+
+// Watcher struct    策略同步watcher, 实现persist.WatcherEx与persist.UpdatableWatcher
+type Watcher struct {
+	id        string
+	transport WatcherTransport
+	mu        sync.RWMutex
+	callback  func(string)
+}
+
+// NewWatcher function    新建watcher并订阅广播, 自己发出的消息会被忽略
+func NewWatcher(transport WatcherTransport) (*Watcher, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		id:        hex.EncodeToString(b),
+		transport: transport,
+	}
+
+	if err := transport.Subscribe(w.receive); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// receive method    处理其他副本的广播
+func (w *Watcher) receive(payload []byte) {
+	msg := &watcherMessage{}
+	if err := json.Unmarshal(payload, msg); err != nil {
+		log.Errorf("casbin watcher decode err: %v", err)
+		return
+	}
+
+	if msg.ID == w.id {
+		return
+	}
+
+	w.mu.RLock()
+	callback := w.callback
+	w.mu.RUnlock()
+
+	if callback != nil {
+		callback(string(payload))
+	}
+}
+
+// publish method    广播消息
+func (w *Watcher) publish(msg *watcherMessage) error {
+	msg.ID = w.id
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return w.transport.Publish(payload)
+}
+
+// SetUpdateCallback method    设置收到广播后的回调
+func (w *Watcher) SetUpdateCallback(callback func(string)) error {
+	w.mu.Lock()
+	w.callback = callback
+	w.mu.Unlock()
+	return nil
+}
+
+// Update method    通知其他副本全量重载
+func (w *Watcher) Update() error {
+	return w.publish(&watcherMessage{Method: updateAll})
+}
+
+// Close method    关闭watcher
+func (w *Watcher) Close() {
+	if err := w.transport.Close(); err != nil {
+		log.Errorf("casbin watcher close err: %v", err)
+	}
+}
+
+// UpdateForAddPolicy method    广播增加策略
+func (w *Watcher) UpdateForAddPolicy(sec, ptype string, params ...string) error {
+	return w.publish(&watcherMessage{Method: updateForAddPolicy, Sec: sec, Ptype: ptype, Rules: [][]string{params}})
+}
+
+// UpdateForRemovePolicy method    广播删除策略
+func (w *Watcher) UpdateForRemovePolicy(sec, ptype string, params ...string) error {
+	return w.publish(&watcherMessage{Method: updateForRemovePolicy, Sec: sec, Ptype: ptype, Rules: [][]string{params}})
+}
+
+// UpdateForRemoveFilteredPolicy method    广播按条件删除策略
+func (w *Watcher) UpdateForRemoveFilteredPolicy(sec, ptype string, fieldIndex int, fieldValues ...string) error {
+	return w.publish(&watcherMessage{Method: updateForRemoveFilteredPolicy, Sec: sec, Ptype: ptype, FieldIndex: fieldIndex, FieldValues: fieldValues})
+}
+
+// UpdateForSavePolicy method    广播全量保存, 其他副本全量重载
+func (w *Watcher) UpdateForSavePolicy(model model.Model) error {
+	return w.publish(&watcherMessage{Method: updateForSavePolicy})
+}
+
+// UpdateForAddPolicies method    广播批量增加策略
+func (w *Watcher) UpdateForAddPolicies(sec string, ptype string, rules ...[]string) error {
+	return w.publish(&watcherMessage{Method: updateForAddPolicies, Sec: sec, Ptype: ptype, Rules: rules})
+}
+
+// UpdateForRemovePolicies method    广播批量删除策略
+func (w *Watcher) UpdateForRemovePolicies(sec string, ptype string, rules ...[]string) error {
+	return w.publish(&watcherMessage{Method: updateForRemovePolicies, Sec: sec, Ptype: ptype, Rules: rules})
+}
+
+// UpdateForUpdatePolicy method    广播修改策略
+func (w *Watcher) UpdateForUpdatePolicy(sec string, ptype string, oldRule, newRule []string) error {
+	return w.publish(&watcherMessage{Method: updateForUpdatePolicy, Sec: sec, Ptype: ptype, Rules: [][]string{oldRule}, NewRules: [][]string{newRule}})
+}
+
+// UpdateForUpdatePolicies method    广播批量修改策略
+func (w *Watcher) UpdateForUpdatePolicies(sec string, ptype string, oldRules, newRules [][]string) error {
+	return w.publish(&watcherMessage{Method: updateForUpdatePolicies, Sec: sec, Ptype: ptype, Rules: oldRules, NewRules: newRules})
+}
+
+// NewIncrementalCallback function    增量应用其他副本的变更, 只修改内存模型不再写入适配器
+func NewIncrementalCallback(e *casbin.SyncedEnforcer) func(string) {
+	return func(payload string) {
+		msg := &watcherMessage{}
+		if err := json.Unmarshal([]byte(payload), msg); err != nil {
+			log.Errorf("casbin watcher decode err: %v", err)
+			return
+		}
+
+		if err := applyMessage(e, msg); err != nil {
+			log.Errorf("casbin watcher apply %s err: %v, reload policy", msg.Method, err)
+			if err = e.LoadPolicy(); err != nil {
+				log.Errorf("casbin watcher reload err: %v", err)
+			}
+		}
+	}
+}
+
+// applyMessage function    在enforcer锁内临时摘掉适配器, 避免共享存储被重复写入
+//
+// 要增加的策略已存在或要删除、修改的策略不存在时返回ErrWatcherDrift, 由调用方全量重载
+func applyMessage(e *casbin.SyncedEnforcer, msg *watcherMessage) error {
+	switch msg.Method {
+	case updateAll, updateForSavePolicy:
+		return e.LoadPolicy()
+	}
+
+	lock := e.GetLock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	adapter := e.GetAdapter()
+	e.SetAdapter(nil)
+	defer e.SetAdapter(adapter)
+
+	var ok bool
+	var err error
+	switch msg.Method {
+	case updateForAddPolicy, updateForAddPolicies:
+		ok, err = e.Enforcer.SelfAddPolicies(msg.Sec, msg.Ptype, msg.Rules)
+	case updateForRemovePolicy, updateForRemovePolicies:
+		ok, err = e.Enforcer.SelfRemovePolicies(msg.Sec, msg.Ptype, msg.Rules)
+	case updateForRemoveFilteredPolicy:
+		ok, err = e.Enforcer.SelfRemoveFilteredPolicy(msg.Sec, msg.Ptype, msg.FieldIndex, msg.FieldValues...)
+	case updateForUpdatePolicy, updateForUpdatePolicies:
+		ok, err = e.Enforcer.SelfUpdatePolicies(msg.Sec, msg.Ptype, msg.Rules, msg.NewRules)
+	default:
+		return fmt.Errorf("unknown method %q", msg.Method)
+	}
+
+	if err == nil && !ok {
+		err = ErrWatcherDrift
+	}
+	return err
+}
+
+// NewWatcherTransport function    根据配置创建广播通道
+func NewWatcherTransport(cnf *CasbinConfig) (WatcherTransport, error) {
+	channel := cnf.WatcherChannel
+	if channel == "" {
+		channel = DefaultWatcherChannel
+	}
+
+	switch cnf.Watcher {
+	case WatcherRedis:
+		return NewRedisTransport(channel)
+	case WatcherMqtt:
+		return NewMqttTransport(channel)
+	default:
+		return nil, errors.New("casbin: unknown watcher " + cnf.Watcher)
+	}
+}
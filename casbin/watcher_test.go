@@ -0,0 +1,165 @@
+package casbin
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Anniext/Arkitektur/system/log"
+	"github.com/casbin/casbin/v2"
+)
+
+func TestMain(m *testing.M) {
+	// 重载失败等分支会写system/log
+	log.NewSlogCore(log.NewSlogOption(log.WithModeOption("dev")))
+	m.Run()
+}
+
+// memoryTransport struct    进程内同步投递的广播通道
+type memoryTransport struct {
+	mu          sync.Mutex
+	subscribers []func(payload []byte)
+}
+
+func (t *memoryTransport) Publish(payload []byte) error {
+	t.mu.Lock()
+	subscribers := append([]func([]byte){}, t.subscribers...)
+	t.mu.Unlock()
+
+	for _, callback := range subscribers {
+		callback(payload)
+	}
+	return nil
+}
+
+func (t *memoryTransport) Subscribe(callback func(payload []byte)) error {
+	t.mu.Lock()
+	t.subscribers = append(t.subscribers, callback)
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *memoryTransport) Close() error {
+	return nil
+}
+
+func TestApplyMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     watcherMessage
+		wantErr error
+		want    [][]string
+	}{
+		{
+			name: "add",
+			msg:  watcherMessage{Method: updateForAddPolicy, Sec: "p", Ptype: "p", Rules: [][]string{{"user", "/api/user", "GET"}}},
+			want: [][]string{{"g", "alice", "admin"}, {"p", "admin", "/api/*", "*"}, {"p", "user", "/api/user", "GET"}},
+		},
+		{
+			name:    "add existing",
+			msg:     watcherMessage{Method: updateForAddPolicies, Sec: "p", Ptype: "p", Rules: [][]string{{"user", "/api/user", "GET"}, {"admin", "/api/*", "*"}}},
+			wantErr: ErrWatcherDrift,
+			want:    [][]string{{"g", "alice", "admin"}, {"p", "admin", "/api/*", "*"}},
+		},
+		{
+			name: "remove",
+			msg:  watcherMessage{Method: updateForRemovePolicy, Sec: "g", Ptype: "g", Rules: [][]string{{"alice", "admin"}}},
+			want: [][]string{{"p", "admin", "/api/*", "*"}},
+		},
+		{
+			name:    "remove missing",
+			msg:     watcherMessage{Method: updateForRemovePolicies, Sec: "g", Ptype: "g", Rules: [][]string{{"bob", "admin"}}},
+			wantErr: ErrWatcherDrift,
+			want:    [][]string{{"g", "alice", "admin"}, {"p", "admin", "/api/*", "*"}},
+		},
+		{
+			name: "remove filtered",
+			msg:  watcherMessage{Method: updateForRemoveFilteredPolicy, Sec: "p", Ptype: "p", FieldIndex: 0, FieldValues: []string{"admin"}},
+			want: [][]string{{"g", "alice", "admin"}},
+		},
+		{
+			name:    "remove filtered missing",
+			msg:     watcherMessage{Method: updateForRemoveFilteredPolicy, Sec: "p", Ptype: "p", FieldIndex: 0, FieldValues: []string{"user"}},
+			wantErr: ErrWatcherDrift,
+			want:    [][]string{{"g", "alice", "admin"}, {"p", "admin", "/api/*", "*"}},
+		},
+		{
+			name: "update",
+			msg:  watcherMessage{Method: updateForUpdatePolicy, Sec: "p", Ptype: "p", Rules: [][]string{{"admin", "/api/*", "*"}}, NewRules: [][]string{{"admin", "/api/*", "GET"}}},
+			want: [][]string{{"g", "alice", "admin"}, {"p", "admin", "/api/*", "GET"}},
+		},
+		{
+			name:    "update missing",
+			msg:     watcherMessage{Method: updateForUpdatePolicies, Sec: "p", Ptype: "p", Rules: [][]string{{"user", "/api/*", "*"}}, NewRules: [][]string{{"user", "/api/*", "GET"}}},
+			wantErr: ErrWatcherDrift,
+			want:    [][]string{{"g", "alice", "admin"}, {"p", "admin", "/api/*", "*"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := NewMemoryAdapter(initialLines...)
+			e := newTestEnforcer(t, adapter)
+
+			msg := tt.msg
+			if err := applyMessage(e, &msg); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("applyMessage = %v, want %v", err, tt.wantErr)
+			}
+			if got := sortedLines(ExportPolicies(e)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("policies = %v, want %v", got, tt.want)
+			}
+			// 增量变更只修改内存模型
+			if got, want := sortedLines(adapter.lines), sortedLines(append([][]string{}, initialLines...)); !reflect.DeepEqual(got, want) {
+				t.Errorf("adapter written: %v", got)
+			}
+		})
+	}
+}
+
+// watch function    enforcer订阅广播, 收到变更后增量应用
+func watch(t *testing.T, e *casbin.SyncedEnforcer, transport WatcherTransport) {
+	t.Helper()
+
+	watcher, err := NewWatcher(transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = e.SetWatcher(watcher); err != nil {
+		t.Fatal(err)
+	}
+	if err = watcher.SetUpdateCallback(NewIncrementalCallback(e)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherSync(t *testing.T) {
+	transport := &memoryTransport{}
+	adapter := NewMemoryAdapter(initialLines...)
+	primary := newTestEnforcer(t, adapter)
+	replica := newTestEnforcer(t, adapter)
+	watch(t, primary, transport)
+
+	// 副本订阅前的变更被漏掉
+	if _, err := primary.AddPolicies([][]string{{"user", "/api/user", "GET"}, {"guest", "/api/public", "GET"}}); err != nil {
+		t.Fatal(err)
+	}
+	watch(t, replica, transport)
+
+	if _, err := primary.AddPolicy("user", "/api/order", "GET"); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"g", "alice", "admin"}, {"p", "admin", "/api/*", "*"}, {"p", "user", "/api/order", "GET"}}
+	if got := sortedLines(ExportPolicies(replica)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("replica after add = %v, want %v", got, want)
+	}
+
+	// 要删除的策略在副本中不存在, 全量重载后与存储一致
+	if _, err := primary.RemovePolicy("user", "/api/user", "GET"); err != nil {
+		t.Fatal(err)
+	}
+	want = [][]string{{"g", "alice", "admin"}, {"p", "admin", "/api/*", "*"}, {"p", "guest", "/api/public", "GET"}, {"p", "user", "/api/order", "GET"}}
+	if got := sortedLines(ExportPolicies(replica)); !reflect.DeepEqual(got, want) {
+		t.Errorf("replica after drift = %v, want %v", got, want)
+	}
+}
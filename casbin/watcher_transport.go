@@ -0,0 +1,91 @@
+package casbin
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Anniext/Arkitektur/cache"
+	"github.com/Anniext/Arkitektur/mqtt"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisTransport struct    基于redis发布订阅的广播通道
+type RedisTransport struct {
+	client  *redis.Client
+	channel string
+	pubSub  *redis.PubSub
+}
+
+// NewRedisTransport function    使用默认redis新建广播通道
+func NewRedisTransport(channel string) (*RedisTransport, error) {
+	client := cache.GetDefaultRedis()
+	if client == nil {
+		return nil, errors.New("casbin redis watcher: redis is not initialized")
+	}
+
+	return &RedisTransport{client: client, channel: channel}, nil
+}
+
+// Publish method    发布消息
+func (t *RedisTransport) Publish(payload []byte) error {
+	return t.client.Publish(context.Background(), t.channel, payload).Err()
+}
+
+// Subscribe method    订阅消息, 确认订阅成功后才返回
+func (t *RedisTransport) Subscribe(callback func(payload []byte)) error {
+	t.pubSub = t.client.Subscribe(context.Background(), t.channel)
+	if _, err := t.pubSub.Receive(context.Background()); err != nil {
+		return err
+	}
+
+	ch := t.pubSub.Channel()
+	go func() {
+		for msg := range ch {
+			callback([]byte(msg.Payload))
+		}
+	}()
+
+	return nil
+}
+
+// Close method    取消订阅
+func (t *RedisTransport) Close() error {
+	if t.pubSub == nil {
+		return nil
+	}
+
+	return t.pubSub.Close()
+}
+
+// MqttTransport struct    基于项目mqtt客户端的广播通道
+type MqttTransport struct {
+	client *mqtt.MQTTClient
+	topic  string
+}
+
+// NewMqttTransport function    使用默认mqtt客户端新建广播通道
+func NewMqttTransport(topic string) (*MqttTransport, error) {
+	client := mqtt.GetDefaultMqtt()
+	if client == nil {
+		return nil, errors.New("casbin mqtt watcher: mqtt is not initialized")
+	}
+
+	return &MqttTransport{client: client, topic: topic}, nil
+}
+
+// Publish method    发布消息
+func (t *MqttTransport) Publish(payload []byte) error {
+	return t.client.Publish(t.topic, payload)
+}
+
+// Subscribe method    订阅消息
+func (t *MqttTransport) Subscribe(callback func(payload []byte)) error {
+	return t.client.Subscribe(t.topic, func(_ string, payload []byte) {
+		callback(payload)
+	})
+}
+
+// Close method    取消订阅
+func (t *MqttTransport) Close() error {
+	return t.client.Unsubscribe(t.topic)
+}
@@ -14,20 +14,27 @@ const (
 	NoErrCode ErrCode = 0   // 无错误码
 )
 
-// 协议错误码
+// 协议错误码, 区间划分与默认HTTP状态(见HTTPStatus):
+//
+//	100-199  会话与长连接错误, 默认500, 已登录/重复登录等客户端状态为409
+//	400-599  请求与基础设施错误, 默认500, 404/400/429/502/503/504单独指定
+//	600-699  jwt认证错误, 401
+//	800-899  casbin鉴权错误, 403
+//	>=1000   业务错误, 默认400, 不存在类404、已存在类409单独指定
 const (
 	ErrCodeOfflineReasonHandlerPanic ErrCode = 100 // 离线处理异常
 	ErrCodeServerClosePushMsgNo      ErrCode = 101 // 离线处理没有消息推送
 	ErrCodeAlreadyLogin              ErrCode = 102 // 已经登录
-	EErrCodeRepeatedLogin            ErrCode = 103 // 已经登录
+	EErrCodeRepeatedLogin            ErrCode = 103 // 重复登录
 	ErrCodeFileNotExist              ErrCode = 404 // 资源不存在
 
-	ErrCodeInvalidParams ErrCode = 500 // 请求参数错误
-	ErrCodeJwtTokenErr   ErrCode = 501 // token错误
-	ErrCodeConnRefuse    ErrCode = 503 // 连接被拒绝
-	ErrCodeNetAbnormal   ErrCode = 504 // 网络异常
-	ErrCodeTimeOut       ErrCode = 505 // 请求超时
-	ErrCodeRedisWriteErr ErrCode = 506 // Redis写入错误
+	ErrCodeInvalidParams   ErrCode = 500 // 请求参数错误
+	ErrCodeJwtTokenErr     ErrCode = 501 // token错误
+	ErrCodeConnRefuse      ErrCode = 503 // 连接被拒绝
+	ErrCodeNetAbnormal     ErrCode = 504 // 网络异常
+	ErrCodeTimeOut         ErrCode = 505 // 请求超时
+	ErrCodeRedisWriteErr   ErrCode = 506 // Redis写入错误
+	ErrCodeTooManyRequests ErrCode = 507 // 请求过于频繁
 
 	ErrCodeJwtTokenIsExpired       ErrCode = 600 // jwt的token过期
 	ErrCodeJwtTokenNotActiveYet    ErrCode = 601 // jwt的没有启用
@@ -35,10 +42,16 @@ const (
 	ErrCodeJwtTokenNotInvalid      ErrCode = 603 // jwt的token不正确
 	ErrCodeAuthorizationRefreshErr ErrCode = 604 // jwt的token刷新错误
 	ErrCodeJwtGenerateErr          ErrCode = 605 // jwt生成失败
+	ErrCodeJwtSigningMethodErr     ErrCode = 606 // jwt签名算法不支持
+	ErrCodeJwtIssuerInvalid        ErrCode = 607 // jwt的签发者不正确
+	ErrCodeJwtAudienceInvalid      ErrCode = 608 // jwt的受众不正确
+	ErrCodeJwtClaimsInvalid        ErrCode = 609 // jwt的声明不正确
 
 	ErrCodeCasbinNotActiveYet    ErrCode = 800 // casbin没有启用
 	ErrCodeCasbinNotPermissions  ErrCode = 801 // casbin没有权限
 	ErrCodeDeleteCasbinGlobalErr ErrCode = 802 // casbin删除全局权限失败
+	ErrCodeCasbinPolicyInvalid   ErrCode = 803 // casbin策略格式错误
+	ErrCodeCasbinImportErr       ErrCode = 804 // casbin策略导入失败
 )
 
 // 业务错误码
@@ -72,7 +85,7 @@ const (
 
 	ErrCodeNotFountRoleGlobal  ErrCode = 1600 // 没找到角色表
 	ErrCodeRoleCodeExist       ErrCode = 1601 // 角色编码已存在
-	ErrCodeNotFoundRoleGlobals ErrCode = 1602 // 没找到角色表
+	ErrCodeNotFoundRoleGlobals ErrCode = 1602 // 没找到角色列表
 	ErrCodeDeleteRoleGlobalErr ErrCode = 1603 //  删除角色表失败
 
 	ErrCodeNotFoundApisGlobals ErrCode = 1700 // 没找到接口表
@@ -90,7 +103,7 @@ const (
 	ErrCodeNotFoundDictTypeGlobal  ErrCode = 2000 // 没找到字典类型表
 	ErrCodeDictTypeGlobalExitErr   ErrCode = 2001 // 字典类型编码已存在
 	ErrCodeDictTypeGlobalDeleteErr ErrCode = 2002 // 字典类型删除失败
-	ErrCodeDictDataGlobalDeleteErr ErrCode = 2002 // 字典数据删除失败
+	ErrCodeDictDataGlobalDeleteErr ErrCode = 2005 // 字典数据删除失败
 	ErrCodeDictInvalidParams       ErrCode = 2003 // 请求获取字典参数错误
 	ErrCodeNotFoundDictDataGlobal  ErrCode = 2004 // 没找到字典数据表
 
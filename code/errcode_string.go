@@ -21,15 +21,22 @@ func _() {
 	_ = x[ErrCodeNetAbnormal-504]
 	_ = x[ErrCodeTimeOut-505]
 	_ = x[ErrCodeRedisWriteErr-506]
+	_ = x[ErrCodeTooManyRequests-507]
 	_ = x[ErrCodeJwtTokenIsExpired-600]
 	_ = x[ErrCodeJwtTokenNotActiveYet-601]
 	_ = x[ErrCodeJwtNotEvenAToken-602]
 	_ = x[ErrCodeJwtTokenNotInvalid-603]
 	_ = x[ErrCodeAuthorizationRefreshErr-604]
 	_ = x[ErrCodeJwtGenerateErr-605]
+	_ = x[ErrCodeJwtSigningMethodErr-606]
+	_ = x[ErrCodeJwtIssuerInvalid-607]
+	_ = x[ErrCodeJwtAudienceInvalid-608]
+	_ = x[ErrCodeJwtClaimsInvalid-609]
 	_ = x[ErrCodeCasbinNotActiveYet-800]
 	_ = x[ErrCodeCasbinNotPermissions-801]
 	_ = x[ErrCodeDeleteCasbinGlobalErr-802]
+	_ = x[ErrCodeCasbinPolicyInvalid-803]
+	_ = x[ErrCodeCasbinImportErr-804]
 	_ = x[ErrCodeGenerateUUidErr-1000]
 	_ = x[ErrCodeGenerateAccountErr-1001]
 	_ = x[ErrCodeUuidGetDBErr-1002]
@@ -68,7 +75,7 @@ func _() {
 	_ = x[ErrCodeNotFoundDictTypeGlobal-2000]
 	_ = x[ErrCodeDictTypeGlobalExitErr-2001]
 	_ = x[ErrCodeDictTypeGlobalDeleteErr-2002]
-	_ = x[ErrCodeDictDataGlobalDeleteErr-2002]
+	_ = x[ErrCodeDictDataGlobalDeleteErr-2005]
 	_ = x[ErrCodeDictInvalidParams-2003]
 	_ = x[ErrCodeNotFoundDictDataGlobal-2004]
 	_ = x[ErrCodeAssetGlobalExist-2100]
@@ -78,7 +85,7 @@ func _() {
 	_ = x[ErrCodeDBSyncErr-9000]
 }
 
-const _ErrCode_name = "无错误码离线处理异常离线处理没有消息推送已经登录已经登录成功资源不存在请求参数错误token错误连接被拒绝网络异常请求超时Redis写入错误jwt的token过期jwt的没有启用没有携带jwt的tokenjwt的token不正确jwt的token刷新错误jwt生成失败casbin没有启用casbin没有权限casbin删除全局权限失败生成uuid错误生成账户失败从数据库获取uuid错误账户不存在账户名已存在密码错误不能禁用自己账户被禁用短信验证码错误角色不存在角色权限不够casbin没找到相同api没找到aes密钥aes解码错误没找路由表没有导出路由删除路由失败没找到用户表生成用户表失败删除用户表失败持久化用户表失败没找到账户表没找到角色表角色编码已存在没找到角色表删除角色表失败没找到接口表接口路径已存在删除接口表错误没找到角色权限表没找到角色接口表删除角色接口表错误删除角色权限表错误没找到记录表删除记录表错误没找到字典类型表字典类型编码已存在字典类型删除失败请求获取字典参数错误没找到字典数据表资产名称已存在资产不存在没找到gpu监控表gpu监控表序列化错误数据库同步错误"
+const _ErrCode_name = "无错误码离线处理异常离线处理没有消息推送已经登录重复登录成功资源不存在请求参数错误token错误连接被拒绝网络异常请求超时Redis写入错误请求过于频繁jwt的token过期jwt的没有启用没有携带jwt的tokenjwt的token不正确jwt的token刷新错误jwt生成失败jwt签名算法不支持jwt的签发者不正确jwt的受众不正确jwt的声明不正确casbin没有启用casbin没有权限casbin删除全局权限失败casbin策略格式错误casbin策略导入失败生成uuid错误生成账户失败从数据库获取uuid错误账户不存在账户名已存在密码错误不能禁用自己账户被禁用短信验证码错误角色不存在角色权限不够casbin没找到相同api没找到aes密钥aes解码错误没找路由表没有导出路由删除路由失败没找到用户表生成用户表失败删除用户表失败持久化用户表失败没找到账户表没找到角色表角色编码已存在没找到角色列表删除角色表失败没找到接口表接口路径已存在删除接口表错误没找到角色权限表没找到角色接口表删除角色接口表错误删除角色权限表错误没找到记录表删除记录表错误没找到字典类型表字典类型编码已存在字典类型删除失败请求获取字典参数错误没找到字典数据表字典数据删除失败资产名称已存在资产不存在没找到gpu监控表gpu监控表序列化错误数据库同步错误"
 
 var _ErrCode_map = map[ErrCode]string{
 	0:    _ErrCode_name[0:12],
@@ -94,60 +101,68 @@ var _ErrCode_map = map[ErrCode]string{
 	504:  _ErrCode_name[149:161],
 	505:  _ErrCode_name[161:173],
 	506:  _ErrCode_name[173:190],
-	600:  _ErrCode_name[190:207],
-	601:  _ErrCode_name[207:225],
-	602:  _ErrCode_name[225:248],
-	603:  _ErrCode_name[248:268],
-	604:  _ErrCode_name[268:291],
-	605:  _ErrCode_name[291:306],
-	800:  _ErrCode_name[306:324],
-	801:  _ErrCode_name[324:342],
-	802:  _ErrCode_name[342:372],
-	1000: _ErrCode_name[372:388],
-	1001: _ErrCode_name[388:406],
-	1002: _ErrCode_name[406:434],
-	1003: _ErrCode_name[434:449],
-	1004: _ErrCode_name[449:467],
-	1005: _ErrCode_name[467:479],
-	1006: _ErrCode_name[479:497],
-	1007: _ErrCode_name[497:512],
-	1008: _ErrCode_name[512:533],
-	1009: _ErrCode_name[533:548],
-	1010: _ErrCode_name[548:566],
-	1011: _ErrCode_name[566:590],
-	1200: _ErrCode_name[590:608],
-	1201: _ErrCode_name[608:623],
-	1300: _ErrCode_name[623:638],
-	1301: _ErrCode_name[638:656],
-	1302: _ErrCode_name[656:674],
-	1400: _ErrCode_name[674:692],
-	1401: _ErrCode_name[692:713],
-	1402: _ErrCode_name[713:734],
-	1403: _ErrCode_name[734:758],
-	1500: _ErrCode_name[758:776],
-	1600: _ErrCode_name[776:794],
-	1601: _ErrCode_name[794:815],
-	1602: _ErrCode_name[815:833],
-	1603: _ErrCode_name[833:854],
-	1700: _ErrCode_name[854:872],
-	1701: _ErrCode_name[872:893],
-	1702: _ErrCode_name[893:914],
-	1800: _ErrCode_name[914:938],
-	1801: _ErrCode_name[938:962],
-	1802: _ErrCode_name[962:989],
-	1803: _ErrCode_name[989:1016],
-	1900: _ErrCode_name[1016:1034],
-	1901: _ErrCode_name[1034:1055],
-	2000: _ErrCode_name[1055:1079],
-	2001: _ErrCode_name[1079:1106],
-	2002: _ErrCode_name[1106:1130],
-	2003: _ErrCode_name[1130:1160],
-	2004: _ErrCode_name[1160:1184],
-	2100: _ErrCode_name[1184:1205],
-	2101: _ErrCode_name[1205:1220],
-	2200: _ErrCode_name[1220:1241],
-	2201: _ErrCode_name[1241:1268],
-	9000: _ErrCode_name[1268:1289],
+	507:  _ErrCode_name[190:208],
+	600:  _ErrCode_name[208:225],
+	601:  _ErrCode_name[225:243],
+	602:  _ErrCode_name[243:266],
+	603:  _ErrCode_name[266:286],
+	604:  _ErrCode_name[286:309],
+	605:  _ErrCode_name[309:324],
+	606:  _ErrCode_name[324:348],
+	607:  _ErrCode_name[348:372],
+	608:  _ErrCode_name[372:393],
+	609:  _ErrCode_name[393:414],
+	800:  _ErrCode_name[414:432],
+	801:  _ErrCode_name[432:450],
+	802:  _ErrCode_name[450:480],
+	803:  _ErrCode_name[480:504],
+	804:  _ErrCode_name[504:528],
+	1000: _ErrCode_name[528:544],
+	1001: _ErrCode_name[544:562],
+	1002: _ErrCode_name[562:590],
+	1003: _ErrCode_name[590:605],
+	1004: _ErrCode_name[605:623],
+	1005: _ErrCode_name[623:635],
+	1006: _ErrCode_name[635:653],
+	1007: _ErrCode_name[653:668],
+	1008: _ErrCode_name[668:689],
+	1009: _ErrCode_name[689:704],
+	1010: _ErrCode_name[704:722],
+	1011: _ErrCode_name[722:746],
+	1200: _ErrCode_name[746:764],
+	1201: _ErrCode_name[764:779],
+	1300: _ErrCode_name[779:794],
+	1301: _ErrCode_name[794:812],
+	1302: _ErrCode_name[812:830],
+	1400: _ErrCode_name[830:848],
+	1401: _ErrCode_name[848:869],
+	1402: _ErrCode_name[869:890],
+	1403: _ErrCode_name[890:914],
+	1500: _ErrCode_name[914:932],
+	1600: _ErrCode_name[932:950],
+	1601: _ErrCode_name[950:971],
+	1602: _ErrCode_name[971:992],
+	1603: _ErrCode_name[992:1013],
+	1700: _ErrCode_name[1013:1031],
+	1701: _ErrCode_name[1031:1052],
+	1702: _ErrCode_name[1052:1073],
+	1800: _ErrCode_name[1073:1097],
+	1801: _ErrCode_name[1097:1121],
+	1802: _ErrCode_name[1121:1148],
+	1803: _ErrCode_name[1148:1175],
+	1900: _ErrCode_name[1175:1193],
+	1901: _ErrCode_name[1193:1214],
+	2000: _ErrCode_name[1214:1238],
+	2001: _ErrCode_name[1238:1265],
+	2002: _ErrCode_name[1265:1289],
+	2003: _ErrCode_name[1289:1319],
+	2004: _ErrCode_name[1319:1343],
+	2005: _ErrCode_name[1343:1367],
+	2100: _ErrCode_name[1367:1388],
+	2101: _ErrCode_name[1388:1403],
+	2200: _ErrCode_name[1403:1424],
+	2201: _ErrCode_name[1424:1451],
+	9000: _ErrCode_name[1451:1472],
 }
 
 func (i ErrCode) String() string {
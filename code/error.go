@@ -0,0 +1,197 @@
+package code
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Error 携带错误码、HTTP状态、i18n消息key、详情与原始错误的error
+type Error struct {
+	Code    ErrCode        // 错误码
+	Status  int            // HTTP状态码, 为0时按错误码分类
+	Key     string         // i18n消息key, 为空时使用MessageKey(Code)
+	Details map[string]any // 结构化详情, 随响应返回
+	cause   error
+}
+
+// New function    新建错误
+func New(errCode ErrCode) *Error {
+	return &Error{Code: errCode}
+}
+
+// Wrap function    包装原始错误, cause为nil时等同New
+func Wrap(errCode ErrCode, cause error) *Error {
+	return &Error{Code: errCode, cause: cause}
+}
+
+// Error method    实现error, 格式为 "消息(错误码): 原始错误"
+func (e *Error) Error() string {
+	msg := Message(e.Code) + "(" + strconv.FormatInt(int64(e.Code), 10) + ")"
+	if e.cause != nil {
+		msg += ": " + e.cause.Error()
+	}
+	return msg
+}
+
+// Unwrap method    返回原始错误, 供errors.Is/As使用
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is method    错误码相同即视为同一错误, 例如 errors.Is(err, code.New(code.ErrCodeXxx))
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && e.Code == t.Code
+}
+
+// WithStatus method    指定HTTP状态码
+func (e *Error) WithStatus(status int) *Error {
+	e.Status = status
+	return e
+}
+
+// WithKey method    指定i18n消息key
+func (e *Error) WithKey(key string) *Error {
+	e.Key = key
+	return e
+}
+
+// WithDetail method    增加一条详情
+func (e *Error) WithDetail(key string, value any) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]any)
+	}
+	e.Details[key] = value
+	return e
+}
+
+// HTTPStatus method    HTTP状态码
+func (e *Error) HTTPStatus() int {
+	if e.Status != 0 {
+		return e.Status
+	}
+	return HTTPStatus(e.Code)
+}
+
+// MessageKey method    i18n消息key
+func (e *Error) MessageKey() string {
+	if e.Key != "" {
+		return e.Key
+	}
+	return MessageKey(e.Code)
+}
+
+func (e *Error) Int32() int32 {
+	return e.Code.Int32()
+}
+
+func (e *Error) UInt32() uint32 {
+	return e.Code.UInt32()
+}
+
+func (e *Error) Int64() int64 {
+	return e.Code.Int64()
+}
+
+func (e *Error) String() string {
+	return Message(e.Code)
+}
+
+// MessageKey function    错误码默认的i18n消息key
+func MessageKey(errCode ErrCode) string {
+	return "errcode." + strconv.FormatInt(int64(errCode), 10)
+}
+
+// AsError function    从error中取出*Error, 其他错误返回nil
+//
+// ErrCode不实现error, 否则以error返回的NoErrCode不为nil, 需要error时使用New(errCode)
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return nil
+}
+
+// httpStatusMu 保护httpStatus, RegisterHTTPStatus可能与请求处理并发
+var httpStatusMu sync.RWMutex
+
+// httpStatus 单独指定HTTP状态的错误码, 未列出的按区间分类
+var httpStatus = map[ErrCode]int{
+	Success:   http.StatusOK,
+	NoErrCode: http.StatusOK,
+
+	ErrCodeAlreadyLogin:          http.StatusConflict,
+	EErrCodeRepeatedLogin:        http.StatusConflict,
+	ErrCodeFileNotExist:          http.StatusNotFound,
+	ErrCodeInvalidParams:         http.StatusBadRequest,
+	ErrCodeJwtTokenErr:           http.StatusUnauthorized,
+	ErrCodeConnRefuse:            http.StatusServiceUnavailable,
+	ErrCodeNetAbnormal:           http.StatusBadGateway,
+	ErrCodeTimeOut:               http.StatusGatewayTimeout,
+	ErrCodeTooManyRequests:       http.StatusTooManyRequests,
+	ErrCodeJwtGenerateErr:        http.StatusInternalServerError,
+	ErrCodeCasbinPolicyInvalid:   http.StatusBadRequest,
+	ErrCodeDeleteCasbinGlobalErr: http.StatusInternalServerError,
+	ErrCodeCasbinImportErr:       http.StatusInternalServerError,
+
+	ErrCodeAccountNotExist:               http.StatusNotFound,
+	ErrCodeUserNameExist:                 http.StatusConflict,
+	ErrCodePasswordErr:                   http.StatusUnauthorized,
+	ErrCodeRoleBan:                       http.StatusForbidden,
+	ErrCodeRoleNotExist:                  http.StatusNotFound,
+	ErrCodeRolePermissionErr:             http.StatusForbidden,
+	ErrCodeCasbinIdenticalAdditionFailed: http.StatusConflict,
+	ErrCodeNotFoundMenusGlobal:           http.StatusNotFound,
+	ErrCodeNotFoundUserShare:             http.StatusNotFound,
+	ErrCodeNotFoundAccountGlobal:         http.StatusNotFound,
+	ErrCodeNotFountRoleGlobal:            http.StatusNotFound,
+	ErrCodeRoleCodeExist:                 http.StatusConflict,
+	ErrCodeNotFoundRoleGlobals:           http.StatusNotFound,
+	ErrCodeNotFoundApisGlobals:           http.StatusNotFound,
+	ErrCodeApiPathExist:                  http.StatusConflict,
+	ErrCodeNotFoundRoleAuthGlobal:        http.StatusNotFound,
+	ErrCodeNotFoundRoleApiGlobal:         http.StatusNotFound,
+	ErrCodeNotFoundRecordsGlobals:        http.StatusNotFound,
+	ErrCodeNotFoundDictTypeGlobal:        http.StatusNotFound,
+	ErrCodeDictTypeGlobalExitErr:         http.StatusConflict,
+	ErrCodeDictInvalidParams:             http.StatusBadRequest,
+	ErrCodeNotFoundDictDataGlobal:        http.StatusNotFound,
+	ErrCodeAssetGlobalExist:              http.StatusConflict,
+	ErrCodeAssetGlobalNotExist:           http.StatusNotFound,
+	ErrCodeNotFoundGpuMonitor:            http.StatusNotFound,
+}
+
+// RegisterHTTPStatus function    指定错误码的HTTP状态, 在启动阶段调用
+func RegisterHTTPStatus(errCode ErrCode, status int) {
+	httpStatusMu.Lock()
+	defer httpStatusMu.Unlock()
+
+	httpStatus[errCode] = status
+}
+
+// HTTPStatus function    错误码对应的HTTP状态, 区间划分见err_code.go
+//
+// 先查单独指定的状态, 其次jwt类(600-699)401, casbin类(800-899)403, 业务错误(>=1000)400,
+// 其余框架错误(100-599, 含100-199的会话错误)500, 会话错误中属于客户端状态的需要在httpStatus中单独指定
+func HTTPStatus(errCode ErrCode) int {
+	httpStatusMu.RLock()
+	status, ok := httpStatus[errCode]
+	httpStatusMu.RUnlock()
+	if ok {
+		return status
+	}
+
+	switch {
+	case errCode >= 600 && errCode < 700:
+		return http.StatusUnauthorized
+	case errCode >= 800 && errCode < 900:
+		return http.StatusForbidden
+	case errCode >= 1000:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
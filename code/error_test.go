@@ -0,0 +1,92 @@
+package code
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		errCode ErrCode
+		want    int
+	}{
+		{Success, http.StatusOK},
+		{NoErrCode, http.StatusOK},
+		{ErrCodeOfflineReasonHandlerPanic, http.StatusInternalServerError},
+		{ErrCodeServerClosePushMsgNo, http.StatusInternalServerError},
+		{ErrCodeAlreadyLogin, http.StatusConflict},
+		{EErrCodeRepeatedLogin, http.StatusConflict},
+		{ErrCodeFileNotExist, http.StatusNotFound},
+		{ErrCodeInvalidParams, http.StatusBadRequest},
+		{ErrCodeRedisWriteErr, http.StatusInternalServerError},
+		{ErrCodeTooManyRequests, http.StatusTooManyRequests},
+		{ErrCodeJwtTokenIsExpired, http.StatusUnauthorized},
+		{ErrCodeJwtGenerateErr, http.StatusInternalServerError},
+		{ErrCodeCasbinNotPermissions, http.StatusForbidden},
+		{ErrCodeCasbinPolicyInvalid, http.StatusBadRequest},
+		{ErrCodeGenerateUUidErr, http.StatusBadRequest},
+		{ErrCodeAccountNotExist, http.StatusNotFound},
+		{ErrCodeUserNameExist, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.errCode.String(), func(t *testing.T) {
+			if got := HTTPStatus(tt.errCode); got != tt.want {
+				t.Errorf("HTTPStatus(%d) = %d, want %d", tt.errCode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAsError(t *testing.T) {
+	custom := New(ErrCodeInvalidParams)
+	tests := []struct {
+		name string
+		err  error
+		want ErrCode
+		nil  bool
+	}{
+		{name: "nil", err: nil, nil: true},
+		{name: "plain error", err: errors.New("x"), nil: true},
+		{name: "error", err: New(ErrCodeTimeOut), want: ErrCodeTimeOut},
+		{name: "wrapped error", err: fmt.Errorf("wrap: %w", custom), want: ErrCodeInvalidParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AsError(tt.err)
+			if tt.nil {
+				if got != nil {
+					t.Fatalf("AsError = %v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.Code != tt.want {
+				t.Fatalf("AsError = %v, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	err := fmt.Errorf("login: %w", Wrap(ErrCodeJwtTokenErr, errors.New("expired")))
+	tests := []struct {
+		name   string
+		target error
+		want   bool
+	}{
+		{name: "same code", target: New(ErrCodeJwtTokenErr), want: true},
+		{name: "other code", target: New(ErrCodeTimeOut), want: false},
+		{name: "plain error", target: errors.New("expired"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(err, tt.target); got != tt.want {
+				t.Errorf("errors.Is = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+//go:build ignore
+
+// gen_builtin 从err_code.go生成builtin.go, 通过 go generate 调用
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "err_code.go", nil, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("// Code generated by \"go run gen_builtin.go\"; DO NOT EDIT.\n\n")
+	buf.WriteString("package code\n\n")
+	buf.WriteString("// builtinErrCodes 框架内置错误码, 由err_code.go生成, 启动时注册到默认注册表\n")
+	buf.WriteString("var builtinErrCodes = []struct {\n\tname    string\n\terrCode ErrCode\n}{\n")
+	for _, name := range errCodeNames(file) {
+		fmt.Fprintf(buf, "\t{%q, %s},\n", name, name)
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile("builtin.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// errCodeNames 按声明顺序收集ErrCode类型的错误码常量, Success与NoErrCode不属于错误码
+func errCodeNames(file *ast.File) []string {
+	names := make([]string, 0)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			if ident, ok := value.Type.(*ast.Ident); !ok || ident.Name != "ErrCode" {
+				continue
+			}
+			for _, name := range value.Names {
+				if strings.HasPrefix(name.Name, "ErrCode") || strings.HasPrefix(name.Name, "EErrCode") {
+					names = append(names, name.Name)
+				}
+			}
+		}
+	}
+	return names
+}
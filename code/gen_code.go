@@ -1,3 +1,4 @@
 package code
 
 //go:generate stringer -linecomment -type ErrCode
+//go:generate go run gen_builtin.go
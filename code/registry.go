@@ -0,0 +1,215 @@
+package code
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	EServiceFramework = "arkitektur"          // 框架协议错误码
+	EServiceCommon    = "arkitektur-business" // 框架内置的通用业务错误码
+
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// CodeRange struct    服务占用的错误码区间, 左右闭区间
+type CodeRange struct {
+	Service string  `json:"service"`
+	Min     ErrCode `json:"min"`
+	Max     ErrCode `json:"max"`
+}
+
+// Entry struct    错误码目录条目
+type Entry struct {
+	Code    ErrCode `json:"code"`
+	Name    string  `json:"name"`
+	Message string  `json:"message"`
+	Service string  `json:"service"`
+	Status  int     `json:"status"` // 读取时按HTTPStatus计算, RegisterHTTPStatus之后立即生效
+}
+
+// Registry struct    错误码注册表, 区间重叠、错误码重复、同服务消息重复都会返回错误
+type Registry struct {
+	mu      sync.RWMutex
+	ranges  []CodeRange
+	entries map[ErrCode]*Entry
+}
+
+// NewRegistry function    新建空注册表
+func NewRegistry() *Registry {
+	return &Registry{entries: make(map[ErrCode]*Entry)}
+}
+
+// RegisterRange method    登记服务的错误码区间, 与已有区间重叠时返回错误
+func (r *Registry) RegisterRange(service string, min, max ErrCode) error {
+	if min > max {
+		return fmt.Errorf("errcode: invalid range %d-%d for %s", min, max, service)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rg := range r.ranges {
+		if min <= rg.Max && rg.Min <= max {
+			return fmt.Errorf("errcode: range %d-%d of %s overlaps %d-%d of %s", min, max, service, rg.Min, rg.Max, rg.Service)
+		}
+	}
+
+	r.ranges = append(r.ranges, CodeRange{Service: service, Min: min, Max: max})
+	return nil
+}
+
+// Register method    登记错误码, 必须落在该服务的区间内
+func (r *Registry) Register(service string, errCode ErrCode, name, message string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inRange := false
+	for _, rg := range r.ranges {
+		if rg.Service == service && errCode >= rg.Min && errCode <= rg.Max {
+			inRange = true
+			break
+		}
+	}
+	if !inRange {
+		return fmt.Errorf("errcode: %s(%d) is outside the ranges of %s", name, errCode, service)
+	}
+
+	if exist, ok := r.entries[errCode]; ok {
+		return fmt.Errorf("errcode: %s(%d) duplicates %s of %s", name, errCode, exist.Name, exist.Service)
+	}
+
+	for _, exist := range r.entries {
+		if exist.Service == service && exist.Message == message {
+			return fmt.Errorf("errcode: message %q of %s(%d) duplicates %s(%d)", message, name, errCode, exist.Name, exist.Code)
+		}
+	}
+
+	r.entries[errCode] = &Entry{
+		Code:    errCode,
+		Name:    name,
+		Message: message,
+		Service: service,
+	}
+	return nil
+}
+
+// withStatus method    复制条目并填入当前的HTTP状态
+func (e *Entry) withStatus() Entry {
+	entry := *e
+	entry.Status = HTTPStatus(e.Code)
+	return entry
+}
+
+// Lookup method    查询错误码条目
+func (r *Registry) Lookup(errCode ErrCode) (Entry, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.entries[errCode]
+	if !ok {
+		return Entry{}, false
+	}
+	return entry.withStatus(), true
+}
+
+// Ranges method    全部区间, 按起始值排序
+func (r *Registry) Ranges() []CodeRange {
+	r.mu.RLock()
+	ranges := append([]CodeRange(nil), r.ranges...)
+	r.mu.RUnlock()
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Min < ranges[j].Min })
+	return ranges
+}
+
+// Catalogue method    全部错误码, 按错误码排序
+func (r *Registry) Catalogue() []Entry {
+	r.mu.RLock()
+	entries := make([]Entry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		entries = append(entries, entry.withStatus())
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Code < entries[j].Code })
+	return entries
+}
+
+// Export method    导出错误码目录, format为json或markdown
+func (r *Registry) Export(format string) ([]byte, error) {
+	entries := r.Catalogue()
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(map[string]any{"ranges": r.Ranges(), "codes": entries}, "", "  ")
+	case FormatMarkdown:
+		buf := &bytes.Buffer{}
+		buf.WriteString("| 错误码 | 名称 | 消息 | 服务 | HTTP状态 |\n")
+		buf.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, entry := range entries {
+			fmt.Fprintf(buf, "| %d | %s | %s | %s | %d |\n", entry.Code, entry.Name,
+				strings.ReplaceAll(entry.Message, "|", "\\|"), entry.Service, entry.Status)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("errcode: unknown export format %q", format)
+	}
+}
+
+var defaultRegistry = newDefaultRegistry()
+
+// newDefaultRegistry function    默认注册表, 预先登记框架内置错误码
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+	mustNil(r.RegisterRange(EServiceFramework, 100, 999))
+	mustNil(r.RegisterRange(EServiceCommon, 1000, 9999))
+
+	for _, builtin := range builtinErrCodes {
+		service := EServiceFramework
+		if builtin.errCode >= 1000 {
+			service = EServiceCommon
+		}
+		mustNil(r.Register(service, builtin.errCode, builtin.name, builtin.errCode.String()))
+	}
+	return r
+}
+
+// mustNil function    内置错误码冲突属于编码错误, 直接panic
+func mustNil(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GetDefaultRegistry function    获取默认注册表
+func GetDefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// RegisterRange function    在默认注册表中登记服务区间, 业务服务应使用10000以上的区间
+func RegisterRange(service string, min, max ErrCode) error {
+	return defaultRegistry.RegisterRange(service, min, max)
+}
+
+// Register function    在默认注册表中登记错误码
+func Register(service string, errCode ErrCode, name, message string) error {
+	return defaultRegistry.Register(service, errCode, name, message)
+}
+
+// MustRegister function    登记错误码, 冲突时panic, 适合在init中使用
+func MustRegister(service string, errCode ErrCode, name, message string) {
+	mustNil(Register(service, errCode, name, message))
+}
+
+// Message function    错误码消息, 优先使用注册表, 未登记时使用stringer生成的消息
+func Message(errCode ErrCode) string {
+	if entry, ok := defaultRegistry.Lookup(errCode); ok {
+		return entry.Message
+	}
+	return errCode.String()
+}
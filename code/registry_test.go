@@ -0,0 +1,71 @@
+package code
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// TestBuiltinErrCodesInSync 修改err_code.go后需要执行go generate, stringer生成的错误码必须全部登记到默认注册表
+func TestBuiltinErrCodesInSync(t *testing.T) {
+	registry := GetDefaultRegistry()
+	for errCode, message := range _ErrCode_map {
+		if errCode == Success || errCode == NoErrCode {
+			continue
+		}
+		entry, ok := registry.Lookup(errCode)
+		if !ok {
+			t.Errorf("errcode %d (%s) missing from builtin.go, run go generate ./code", errCode, message)
+			continue
+		}
+		if entry.Message != message {
+			t.Errorf("errcode %d message = %q, want %q", errCode, entry.Message, message)
+		}
+	}
+	if len(builtinErrCodes) != len(_ErrCode_map)-2 {
+		t.Errorf("builtin.go has %d codes, stringer has %d", len(builtinErrCodes), len(_ErrCode_map)-2)
+	}
+}
+
+func TestRegisterHTTPStatus(t *testing.T) {
+	r := NewRegistry()
+	if err := r.RegisterRange("demo", 20000, 20099); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("demo", 20001, "ErrDemoLocked", "locked"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		httpStatusMu.Lock()
+		delete(httpStatus, 20001)
+		delete(httpStatus, 20002)
+		httpStatusMu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterHTTPStatus(20002, http.StatusTeapot)
+		}()
+		go func() {
+			defer wg.Done()
+			HTTPStatus(20001)
+		}()
+	}
+	wg.Wait()
+
+	if entry, _ := r.Lookup(20001); entry.Status != http.StatusBadRequest {
+		t.Fatalf("status before register = %d, want %d", entry.Status, http.StatusBadRequest)
+	}
+
+	RegisterHTTPStatus(20001, http.StatusLocked)
+	if entry, _ := r.Lookup(20001); entry.Status != http.StatusLocked {
+		t.Errorf("lookup status = %d, want %d", entry.Status, http.StatusLocked)
+	}
+	if entries := r.Catalogue(); len(entries) != 1 || entries[0].Status != http.StatusLocked {
+		t.Errorf("catalogue = %+v", entries)
+	}
+}
@@ -3,6 +3,7 @@ package common
 import (
 	"fmt"
 	"github.com/Anniext/Arkitektur/code"
+	"github.com/Anniext/Arkitektur/i18n"
 	"github.com/Anniext/Arkitektur/utils"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -17,41 +18,70 @@ type CodeApi struct {
 	UseTime string `json:"useTime"`
 }
 
+// Success method    返回成功
+func (api *CodeApi) Success(ctx *gin.Context, data any) {
+	api.Result(ctx, code.Success.Int32(), data, message(ctx, code.Success))
+}
+
+// Sucess method    返回成功
+//
+// Deprecated: 拼写错误, 使用Success
 func (api *CodeApi) Sucess(ctx *gin.Context, data any) {
-	c := code.Success
-	api.Result(ctx, c.Int32(), data, c.String())
+	api.Success(ctx, data)
 }
 
+// Fail method    按错误码分类返回HTTP状态, *code.Error的详情放入Data
 func (api *CodeApi) Fail(ctx *gin.Context, errCode code.IErrCode) {
-	if errCode == code.ErrCodeJwtTokenIsExpired {
-		api.UnauthorizedResult(ctx, errCode.Int32(), nil, errCode.String())
-	} else {
-		api.Result(ctx, errCode.Int32(), nil, errCode.String())
+	var data any
+	status := code.HTTPStatus(code.ErrCode(errCode.Int32()))
+	if e, ok := errCode.(*code.Error); ok {
+		status = e.HTTPStatus()
+		if len(e.Details) != 0 {
+			data = e.Details
+		}
 	}
+	api.StatusResult(ctx, status, errCode.Int32(), data, message(ctx, errCode))
+}
+
+// FailError method    返回任意error, 非code错误按处理异常返回500
+func (api *CodeApi) FailError(ctx *gin.Context, err error) {
+	e := code.AsError(err)
+	if e == nil {
+		e = code.Wrap(code.ErrCodeOfflineReasonHandlerPanic, err)
+	}
+	api.Fail(ctx, e)
 }
 
 // UnauthorizedResult method    注入401请求返包
 func (api *CodeApi) UnauthorizedResult(ctx *gin.Context, code int32, data interface{}, msg string) {
-	api.Code = code
-	api.Message = msg
-	api.Data = data
-	api.NowTime = time.Now().Unix()
-	if useTime := api.getUserTime(ctx); len(useTime) != 0 {
-		api.UseTime = api.getUserTime(ctx)
-	}
-	ctx.JSON(http.StatusUnauthorized, api)
+	api.StatusResult(ctx, http.StatusUnauthorized, code, data, msg)
 }
 
 // Result method    注入请求返包
 func (api *CodeApi) Result(ctx *gin.Context, code int32, data interface{}, msg string) {
+	api.StatusResult(ctx, http.StatusOK, code, data, msg)
+}
+
+// StatusResult method    注入指定HTTP状态的请求返包, 格式按Accept协商
+func (api *CodeApi) StatusResult(ctx *gin.Context, status int, code int32, data interface{}, msg string) {
+	api.fill(ctx, code, data, msg)
+	Render(ctx, status, api)
+}
+
+// fill method    填充信封字段
+func (api *CodeApi) fill(ctx *gin.Context, code int32, data interface{}, msg string) {
 	api.Code = code
 	api.Message = msg
 	api.Data = data
 	api.NowTime = time.Now().Unix()
 	if useTime := api.getUserTime(ctx); len(useTime) != 0 {
-		api.UseTime = api.getUserTime(ctx)
+		api.UseTime = useTime
 	}
-	ctx.JSON(http.StatusOK, api)
+}
+
+// message function    错误码的本地化消息
+func message(ctx *gin.Context, errCode code.IErrCode) string {
+	return i18n.Message(i18n.Locale(ctx), errCode)
 }
 
 // getUserTime method    获取请求统计时间
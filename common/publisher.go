@@ -0,0 +1,8 @@
+package common
+
+// Publisher interface    服务端推送通道, websocket与sse会话管理器都实现了该接口, 业务层无需关心具体传输方式
+type Publisher interface {
+	Push(uid int32, msgNo uint32, body []byte) bool // 推送给uid, 对方不在线时返回false
+	PushAll(msgNo uint32, body []byte)              // 推送给全部连接
+	PushWork(uid int32, fn func()) bool             // 提交到uid会话的工作队列, 在会话协程中执行
+}
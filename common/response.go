@@ -0,0 +1,229 @@
+package common
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	DefaultPageSize = 20   // 默认每页数量
+	MaxPageSize     = 1000 // 每页数量上限
+)
+
+// offeredFormats 支持协商的响应格式, 第一个为默认格式
+var offeredFormats = []string{binding.MIMEJSON, binding.MIMEMSGPACK, binding.MIMEMSGPACK2, binding.MIMEPROTOBUF}
+
+// Render function    按Accept协商格式输出响应信封, 支持json、msgpack与protobuf
+func Render(ctx *gin.Context, status int, api *CodeApi) {
+	switch ctx.NegotiateFormat(offeredFormats...) {
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		ctx.Render(status, render.MsgPack{Data: api})
+	case binding.MIMEPROTOBUF:
+		message, err := api.ToStruct()
+		if err != nil {
+			ctx.JSON(status, api)
+			return
+		}
+		ctx.ProtoBuf(status, message)
+	default:
+		ctx.JSON(status, api)
+	}
+}
+
+// ToStruct method    转换为google.protobuf.Struct, 字段与json一致, Data为proto.Message时按protojson转换
+func (api *CodeApi) ToStruct() (*structpb.Struct, error) {
+	var data []byte
+	var err error
+	if message, ok := api.Data.(proto.Message); ok {
+		data, err = protojson.Marshal(message)
+		if err != nil {
+			return nil, err
+		}
+		data, err = json.Marshal(map[string]any{"code": api.Code, "message": api.Message,
+			"data": json.RawMessage(data), "nowTime": api.NowTime, "useTime": api.UseTime})
+	} else {
+		data, err = json.Marshal(api)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(fields)
+}
+
+// Success function    返回成功, 无需自行创建CodeApi
+func Success(ctx *gin.Context, data any) {
+	(&CodeApi{}).Success(ctx, data)
+}
+
+// Fail function    返回错误码
+func Fail(ctx *gin.Context, errCode code.IErrCode) {
+	(&CodeApi{}).Fail(ctx, errCode)
+}
+
+// FailError function    返回任意error
+func FailError(ctx *gin.Context, err error) {
+	(&CodeApi{}).FailError(ctx, err)
+}
+
+// StatusResult function    返回指定HTTP状态的响应
+func StatusResult(ctx *gin.Context, status int, code int32, data any, msg string) {
+	(&CodeApi{}).StatusResult(ctx, status, code, data, msg)
+}
+
+// PageQuery struct    分页参数, 页码从1开始
+type PageQuery struct {
+	Page     int `form:"page" json:"page"`
+	PageSize int `form:"pageSize" json:"pageSize"`
+}
+
+// Normalize method    修正非法的页码与每页数量
+func (q PageQuery) Normalize() PageQuery {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 {
+		q.PageSize = DefaultPageSize
+	}
+	if q.PageSize > MaxPageSize {
+		q.PageSize = MaxPageSize
+	}
+	return q
+}
+
+// Offset method    查询偏移量
+func (q PageQuery) Offset() int {
+	q = q.Normalize()
+	return (q.Page - 1) * q.PageSize
+}
+
+// Limit method    查询数量
+func (q PageQuery) Limit() int {
+	return q.Normalize().PageSize
+}
+
+// PageResult struct    分页响应, 放在CodeApi.Data中返回
+type PageResult[T any] struct {
+	Items    []T   `json:"items"`
+	Total    int64 `json:"total"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"pageSize"`
+}
+
+// NewPageResult function    新建分页响应, items为nil时返回空数组
+func NewPageResult[T any](items []T, total int64, query PageQuery) *PageResult[T] {
+	if items == nil {
+		items = make([]T, 0)
+	}
+
+	query = query.Normalize()
+	return &PageResult[T]{
+		Items:    items,
+		Total:    total,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}
+}
+
+// Page function    返回分页数据
+func Page[T any](ctx *gin.Context, items []T, total int64, query PageQuery) {
+	Success(ctx, NewPageResult(items, total, query))
+}
+
+// File function    下载本地文件, name为空时按inline返回, 文件不存在时返回ErrCodeFileNotExist
+func File(ctx *gin.Context, filepath, name string) {
+	if info, err := os.Stat(filepath); err != nil || info.IsDir() {
+		Fail(ctx, code.ErrCodeFileNotExist)
+		return
+	}
+
+	if name == "" {
+		ctx.File(filepath)
+		return
+	}
+	ctx.FileAttachment(filepath, name)
+}
+
+// Stream function    以附件形式输出数据流, size未知时传-1
+func Stream(ctx *gin.Context, name, contentType string, size int64, reader io.Reader) {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	var headers map[string]string
+	if name != "" {
+		headers = map[string]string{"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": name})}
+	}
+	ctx.DataFromReader(http.StatusOK, size, contentType, reader, headers)
+}
+
+// SSEvent struct    服务端事件, Data会包装为CodeApi信封
+type SSEvent struct {
+	Id    string        // 事件id, 客户端重连时通过Last-Event-ID带回
+	Event string        // 事件名, 为空时客户端按message处理
+	Retry uint          // 建议的重连间隔(毫秒)
+	Data  any           // 数据
+	Err   code.IErrCode // 不为nil时按失败信封发送
+}
+
+// envelope method    事件数据的响应信封
+func (e SSEvent) envelope(ctx *gin.Context) *CodeApi {
+	api := &CodeApi{}
+	if e.Err != nil && code.ErrCode(e.Err.Int32()) != code.Success {
+		api.fill(ctx, e.Err.Int32(), e.Data, message(ctx, e.Err))
+	} else {
+		api.fill(ctx, code.Success.Int32(), e.Data, message(ctx, code.Success))
+	}
+	return api
+}
+
+// sseFieldReplacer 去掉事件名与id中的换行, 防止注入额外的字段或事件
+var sseFieldReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+// WriteSSEvent function    写入一条服务端事件
+func WriteSSEvent(ctx *gin.Context, event SSEvent) {
+	ctx.Render(-1, sse.Event{
+		Id:    sseFieldReplacer.Replace(event.Id),
+		Event: sseFieldReplacer.Replace(event.Event),
+		Retry: event.Retry,
+		Data:  event.envelope(ctx),
+	})
+	ctx.Writer.Flush()
+}
+
+// SSE function    持续推送events中的事件, 通道关闭或客户端断开时返回
+func SSE(ctx *gin.Context, events <-chan SSEvent) {
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			WriteSSEvent(ctx, event)
+			return true
+		}
+	})
+}
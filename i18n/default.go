@@ -0,0 +1,88 @@
+package i18n
+
+import (
+	"embed"
+	"net/http"
+
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/Anniext/Arkitektur/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultLocale = "zh"
+	ELocaleClaim  = "locale" // token中的语言声明
+	ELocaleKey    = "locale" // gin上下文中指定语言的key, 优先级最高
+	ELocaleQuery  = "lang"   // query参数
+)
+
+//go:embed locales
+var localesFS embed.FS
+
+var defaultBundle = newDefaultBundle()
+
+// newDefaultBundle function    加载内置的错误码消息
+func newDefaultBundle() *Bundle {
+	b := NewBundle(DefaultLocale)
+	if err := b.LoadFS(localesFS, "locales"); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// GetDefaultBundle function    获取默认消息目录, 业务可通过LoadFS追加自己的目录
+func GetDefaultBundle() *Bundle {
+	return defaultBundle
+}
+
+// T function    翻译key, 找不到时返回fallback
+func T(locale, key, fallback string) string {
+	if message, ok := defaultBundle.Translate(locale, key); ok {
+		return message
+	}
+	return fallback
+}
+
+// Message function    错误码的本地化消息, 数值不变, 找不到翻译时使用注册表中的消息
+func Message(locale string, errCode code.IErrCode) string {
+	key := code.MessageKey(code.ErrCode(errCode.Int32()))
+	if e, ok := errCode.(*code.Error); ok {
+		key = e.MessageKey()
+	}
+	return T(locale, key, code.Message(code.ErrCode(errCode.Int32())))
+}
+
+// Negotiate function    根据Accept-Language选择语言
+func Negotiate(acceptLanguage string) string {
+	return defaultBundle.Match(acceptLanguage)
+}
+
+// LocaleFromRequest function    依次从token声明、lang参数、Accept-Language中选择语言
+func LocaleFromRequest(req *http.Request, claims map[string]any) string {
+	if claims != nil {
+		if locale, ok := defaultBundle.Resolve(utils.GetMapSpecificValue[string](claims, ELocaleClaim)); ok {
+			return locale
+		}
+	}
+
+	if req == nil {
+		return defaultBundle.DefaultLocale()
+	}
+
+	if locale, ok := defaultBundle.Resolve(req.URL.Query().Get(ELocaleQuery)); ok {
+		return locale
+	}
+	return Negotiate(req.Header.Get("Accept-Language"))
+}
+
+// Locale function    当前请求的语言, 结果缓存在gin上下文中
+func Locale(ctx *gin.Context) string {
+	if locale := ctx.GetString(ELocaleKey); locale != "" {
+		return locale
+	}
+
+	claims, _ := ctx.Value("claims").(map[string]any)
+	locale := LocaleFromRequest(ctx.Request, claims)
+	ctx.Set(ELocaleKey, locale)
+	return locale
+}
@@ -0,0 +1,227 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	FormatYaml = "yaml"
+	FormatJson = "json"
+)
+
+// Bundle struct    多语言消息目录, key为点分隔的层级, 例如 errcode.600
+type Bundle struct {
+	mu            sync.RWMutex
+	defaultLocale string
+	messages      map[string]map[string]string
+}
+
+// NewBundle function    新建消息目录, 找不到语言时回退到defaultLocale
+func NewBundle(defaultLocale string) *Bundle {
+	return &Bundle{
+		defaultLocale: normalize(defaultLocale),
+		messages:      make(map[string]map[string]string),
+	}
+}
+
+// DefaultLocale method    默认语言
+func (b *Bundle) DefaultLocale() string {
+	return b.defaultLocale
+}
+
+// Add method    增加消息, 已有的key会被覆盖
+func (b *Bundle) Add(locale string, messages map[string]string) {
+	locale = normalize(locale)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.messages[locale] == nil {
+		b.messages[locale] = make(map[string]string, len(messages))
+	}
+	for key, message := range messages {
+		b.messages[locale][key] = message
+	}
+}
+
+// LoadBytes method    加载yaml或json格式的消息, 嵌套结构会展开成点分隔的key
+func (b *Bundle) LoadBytes(locale string, data []byte, format string) error {
+	var raw any
+	var err error
+	switch format {
+	case FormatYaml, "yml":
+		err = yaml.Unmarshal(data, &raw)
+	case FormatJson:
+		err = json.Unmarshal(data, &raw)
+	default:
+		err = fmt.Errorf("i18n: unknown format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	messages := make(map[string]string)
+	flatten("", raw, messages)
+	b.Add(locale, messages)
+	return nil
+}
+
+// LoadFS method    加载目录下的 <语言>.yaml、<语言>.yml、<语言>.json 文件
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		ext := path.Ext(entry.Name())
+		format := strings.TrimPrefix(ext, ".")
+		if format != FormatYaml && format != "yml" && format != FormatJson {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if err = b.LoadBytes(strings.TrimSuffix(entry.Name(), ext), data, format); err != nil {
+			return fmt.Errorf("i18n: load %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Locales method    已加载的语言
+func (b *Bundle) Locales() []string {
+	b.mu.RLock()
+	locales := make([]string, 0, len(b.messages))
+	for locale := range b.messages {
+		locales = append(locales, locale)
+	}
+	b.mu.RUnlock()
+
+	sort.Strings(locales)
+	return locales
+}
+
+// Translate method    查找消息, 依次尝试 zh-cn、zh 与默认语言
+func (b *Bundle) Translate(locale, key string) (string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, candidate := range b.candidates(normalize(locale)) {
+		if message, ok := b.messages[candidate][key]; ok {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// candidates method    查找顺序
+func (b *Bundle) candidates(locale string) []string {
+	candidates := make([]string, 0, 3)
+	if locale != "" {
+		candidates = append(candidates, locale)
+		if base, _, ok := strings.Cut(locale, "-"); ok {
+			candidates = append(candidates, base)
+		}
+	}
+	return append(candidates, b.defaultLocale)
+}
+
+// Match method    按Accept-Language的权重选择已加载的语言, 都不支持时返回默认语言
+func (b *Bundle) Match(acceptLanguage string) string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+
+	tags := make([]weighted, 0)
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q <= 0 {
+			continue // q=0表示不接受该语言
+		}
+		tags = append(tags, weighted{locale: normalize(tag), q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, tag := range tags {
+		if locale, ok := b.Resolve(tag.locale); ok {
+			return locale
+		}
+	}
+	return b.defaultLocale
+}
+
+// Resolve method    返回已加载的语言, zh-cn未加载时尝试zh
+func (b *Bundle) Resolve(locale string) (string, bool) {
+	locale = normalize(locale)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if _, ok := b.messages[locale]; ok {
+		return locale, true
+	}
+	if base, _, ok := strings.Cut(locale, "-"); ok {
+		if _, ok = b.messages[base]; ok {
+			return base, true
+		}
+	}
+	return "", false
+}
+
+// normalize function    统一为小写并使用 - 分隔, 例如 zh_CN 转为 zh-cn
+func normalize(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// flatten function    展开嵌套结构
+func flatten(prefix string, value any, out map[string]string) {
+	join := func(key any) string {
+		if prefix == "" {
+			return fmt.Sprint(key)
+		}
+		return prefix + "." + fmt.Sprint(key)
+	}
+
+	switch v := value.(type) {
+	case map[any]any:
+		for key, item := range v {
+			flatten(join(key), item, out)
+		}
+	case map[string]any:
+		for key, item := range v {
+			flatten(join(key), item, out)
+		}
+	case nil:
+	default:
+		if prefix != "" {
+			out[prefix] = fmt.Sprint(v)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package i18n
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Anniext/Arkitektur/code"
+)
+
+func newTestBundle() *Bundle {
+	b := NewBundle("zh")
+	b.Add("zh", map[string]string{"hello": "你好"})
+	b.Add("en", map[string]string{"hello": "hello"})
+	b.Add("zh-TW", map[string]string{"hello": "妳好"})
+	return b
+}
+
+func TestMatch(t *testing.T) {
+	b := newTestBundle()
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           string
+	}{
+		{name: "empty", acceptLanguage: "", want: "zh"},
+		{name: "exact", acceptLanguage: "en", want: "en"},
+		{name: "region falls back to base", acceptLanguage: "en-US", want: "en"},
+		{name: "region loaded", acceptLanguage: "zh_TW", want: "zh-tw"},
+		{name: "highest q wins", acceptLanguage: "zh;q=0.5, en;q=0.9", want: "en"},
+		{name: "missing q is 1", acceptLanguage: "en;q=0.8, zh", want: "zh"},
+		{name: "equal q keeps order", acceptLanguage: "en;q=0.7, zh;q=0.7", want: "en"},
+		{name: "unsupported skipped", acceptLanguage: "fr-FR, fr;q=0.9, en;q=0.1", want: "en"},
+		{name: "q=0 not acceptable", acceptLanguage: "fr, en;q=0", want: "zh"},
+		{name: "wildcard ignored", acceptLanguage: "*", want: "zh"},
+		{name: "invalid q treated as 1", acceptLanguage: "fr;q=0.9, en;q=abc", want: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.Match(tt.acceptLanguage); got != tt.want {
+				t.Errorf("Match(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	b := newTestBundle()
+	tests := []struct {
+		locale string
+		want   string
+		ok     bool
+	}{
+		{locale: "EN", want: "en", ok: true},
+		{locale: "en-GB", want: "en", ok: true},
+		{locale: "zh-tw", want: "zh-tw", ok: true},
+		{locale: "fr", ok: false},
+		{locale: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			got, ok := b.Resolve(tt.locale)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Resolve(%q) = %q, %v, want %q, %v", tt.locale, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestLocaleFromRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		claims         map[string]any
+		query          string
+		acceptLanguage string
+		want           string
+	}{
+		{name: "claim wins", claims: map[string]any{ELocaleClaim: "en"}, query: "zh", acceptLanguage: "zh", want: "en"},
+		{name: "unknown claim falls through", claims: map[string]any{ELocaleClaim: "fr"}, query: "en", want: "en"},
+		{name: "query before header", query: "en", acceptLanguage: "zh", want: "en"},
+		{name: "header", acceptLanguage: "en-US,en;q=0.9", want: "en"},
+		{name: "default", acceptLanguage: "fr", want: DefaultLocale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/ping?"+ELocaleQuery+"="+tt.query, nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			if got := LocaleFromRequest(req, tt.claims); got != tt.want {
+				t.Errorf("LocaleFromRequest = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := LocaleFromRequest(nil, nil); got != DefaultLocale {
+		t.Errorf("LocaleFromRequest(nil) = %q, want %q", got, DefaultLocale)
+	}
+}
+
+// TestCataloguesComplete 每个内置错误码在全部内置语言中都要有翻译
+func TestCataloguesComplete(t *testing.T) {
+	b := GetDefaultBundle()
+	for _, locale := range []string{"zh", "en"} {
+		for _, entry := range code.GetDefaultRegistry().Catalogue() {
+			if _, ok := b.messages[locale][code.MessageKey(entry.Code)]; !ok {
+				t.Errorf("%s: missing %s for %s", locale, code.MessageKey(entry.Code), entry.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ClaimStrings aud声明, 兼容单个字符串与字符串数组两种写法
+type ClaimStrings []string
+
+func (s *ClaimStrings) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+	case string:
+		*s = ClaimStrings{v}
+	case []any:
+		list := make(ClaimStrings, 0, len(v))
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("invalid aud item: %v", item)
+			}
+			list = append(list, str)
+		}
+		*s = list
+	default:
+		return fmt.Errorf("invalid aud: %v", value)
+	}
+
+	return nil
+}
+
+func (s ClaimStrings) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 {
+		return json.Marshal(s[0])
+	}
+
+	return json.Marshal([]string(s))
+}
+
+// RegisteredClaims 标准注册声明, 业务声明内嵌后即可通过ParseClaims解析
+type RegisteredClaims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  ClaimStrings `json:"aud,omitempty"`
+	ExpiresAt int64        `json:"exp,omitempty"`
+	NotBefore int64        `json:"nbf,omitempty"`
+	IssuedAt  int64        `json:"iat,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+}
+
+// IClaims 携带标准注册声明的自定义声明
+type IClaims interface {
+	jwt.Claims
+	GetRegisteredClaims() *RegisteredClaims
+}
+
+// GetRegisteredClaims method    获取标准注册声明
+func (c *RegisteredClaims) GetRegisteredClaims() *RegisteredClaims {
+	return c
+}
+
+// Valid method    校验时间声明, 不带时钟偏差
+func (c *RegisteredClaims) Valid() error {
+	return c.verify(time.Now(), 0, "", nil)
+}
+
+// verify method    校验时间、签发者与受众声明
+func (c *RegisteredClaims) verify(now time.Time, leeway time.Duration, issuer string, audience []string) error {
+	var flags uint32
+	var messages []string
+
+	if c.ExpiresAt != 0 && now.Add(-leeway).Unix() > c.ExpiresAt {
+		flags |= jwt.ValidationErrorExpired
+		messages = append(messages, "token is expired")
+	}
+
+	if c.NotBefore != 0 && now.Add(leeway).Unix() < c.NotBefore {
+		flags |= jwt.ValidationErrorNotValidYet
+		messages = append(messages, "token is not valid yet")
+	}
+
+	if c.IssuedAt != 0 && now.Add(leeway).Unix() < c.IssuedAt {
+		flags |= jwt.ValidationErrorIssuedAt
+		messages = append(messages, "token used before issued")
+	}
+
+	if issuer != "" && c.Issuer != issuer {
+		flags |= jwt.ValidationErrorIssuer
+		messages = append(messages, "token has invalid issuer")
+	}
+
+	if len(audience) != 0 && !slices.ContainsFunc(c.Audience, func(aud string) bool {
+		return slices.Contains(audience, aud)
+	}) {
+		flags |= jwt.ValidationErrorAudience
+		messages = append(messages, "token has invalid audience")
+	}
+
+	if flags != 0 {
+		return jwt.NewValidationError(strings.Join(messages, "; "), flags)
+	}
+
+	return nil
+}
+
+// toRegisteredClaims function    从MapClaims中提取标准注册声明
+func toRegisteredClaims(claims jwt.MapClaims) (*RegisteredClaims, error) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &RegisteredClaims{}
+	if err = json.Unmarshal(data, rc); err != nil {
+		return nil, err
+	}
+
+	return rc, nil
+}
+
+// ParseClaims function    解析jwt token到自定义声明, T需内嵌RegisteredClaims
+func ParseClaims[T any, PT interface {
+	*T
+	IClaims
+}](tokenString string) (*T, code.ErrCode) {
+	claims := PT(new(T))
+	if errCode := NewJwt().parse(tokenString, claims); errCode != code.NoErrCode {
+		return nil, errCode
+	}
+
+	return claims, code.NoErrCode
+}
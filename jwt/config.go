@@ -1,7 +1,12 @@
 package jwt
 
+import "time"
+
 type JwtConfig struct {
 	JwtSigningKey string
+	Issuer        string        // 签发者, 为空时不校验iss
+	Audience      []string      // 受众, 为空时不校验aud
+	Leeway        time.Duration // 时钟偏差容忍时间
 }
 type Option func(*JwtConfig)
 
@@ -11,6 +16,24 @@ func WithJwtSigningKeyOption(jwtSigningKey string) Option {
 	}
 }
 
+func WithIssuerOption(issuer string) Option {
+	return func(c *JwtConfig) {
+		c.Issuer = issuer
+	}
+}
+
+func WithAudienceOption(audience ...string) Option {
+	return func(c *JwtConfig) {
+		c.Audience = audience
+	}
+}
+
+func WithLeewayOption(leeway time.Duration) Option {
+	return func(c *JwtConfig) {
+		c.Leeway = leeway
+	}
+}
+
 func NewCacheOption(options ...Option) {
 	defaultJwtConfig = &JwtConfig{}
 	for _, option := range options {
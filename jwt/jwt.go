@@ -3,6 +3,7 @@ package jwt
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/Anniext/Arkitektur/code"
 	"github.com/Anniext/Arkitektur/utils"
 	"time"
@@ -35,7 +36,51 @@ func (j *Jwt) GenerateJwtToken(claimsMap jwt.MapClaims) (string, code.ErrCode) {
 		claimsMap["exp"] = time.Now().Add(time.Minute * 15).Unix()
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claimsMap)
+	cnf := j.getConfig()
+	if _, ok := claimsMap["iss"]; !ok && cnf.Issuer != "" {
+		claimsMap["iss"] = cnf.Issuer
+	}
+	if _, ok := claimsMap["aud"]; !ok && len(cnf.Audience) != 0 {
+		claimsMap["aud"] = ClaimStrings(cnf.Audience)
+	}
+
+	return j.sign(claimsMap)
+}
+
+// GenerateClaimsToken method    根据自定义声明生成jwt密钥
+func (j *Jwt) GenerateClaimsToken(claims IClaims) (string, code.ErrCode) {
+	now := time.Now()
+	rc := claims.GetRegisteredClaims()
+	rc.IssuedAt = now.Unix()
+	rc.NotBefore = now.Unix()
+	if rc.ExpiresAt == 0 {
+		rc.ExpiresAt = now.Add(time.Minute * 15).Unix()
+	}
+
+	cnf := j.getConfig()
+	if rc.Issuer == "" {
+		rc.Issuer = cnf.Issuer
+	}
+	if len(rc.Audience) == 0 {
+		rc.Audience = cnf.Audience
+	}
+
+	return j.sign(claims)
+}
+
+// ParseJwtToken method    解析jwt token
+func (j *Jwt) ParseJwtToken(tokenString string) (map[string]interface{}, code.ErrCode) {
+	claims := make(jwt.MapClaims)
+	if errCode := j.parse(tokenString, claims); errCode != code.NoErrCode {
+		return nil, errCode
+	}
+
+	return claims, code.NoErrCode
+}
+
+// sign method    签名生成token
+func (j *Jwt) sign(claims jwt.Claims) (string, code.ErrCode) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString(j.getSecretKey())
 	if err != nil {
 		return "", code.ErrCodeJwtGenerateErr
@@ -44,31 +89,79 @@ func (j *Jwt) GenerateJwtToken(claimsMap jwt.MapClaims) (string, code.ErrCode) {
 	return tokenString, code.NoErrCode
 }
 
-// ParseJwtToken method    解析jwt token
-func (j *Jwt) ParseJwtToken(tokenString string) (map[string]interface{}, code.ErrCode) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return j.getSecretKey(), nil
-	})
-	if err != nil {
-		var ve *jwt.ValidationError
-		if errors.As(err, &ve) {
-			if ve.Errors&jwt.ValidationErrorExpired != 0 {
-				return nil, code.ErrCodeJwtTokenIsExpired
-			} else if ve.Errors&jwt.ValidationErrorMalformed != 0 {
-				return nil, code.ErrCodeJwtNotEvenAToken
-			} else if ve.Errors&jwt.ValidationErrorNotValidYet != 0 {
-				return nil, code.ErrCodeJwtTokenNotInvalid
-			} else {
-				return nil, code.ErrCodeJwtTokenNotActiveYet
-			}
+// parse method    校验签名后按配置的时钟偏差校验声明
+func (j *Jwt) parse(tokenString string, claims jwt.Claims) code.ErrCode {
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	if _, err := parser.ParseWithClaims(tokenString, claims, j.keyFunc); err != nil {
+		return classifyError(err)
+	}
+
+	var rc *RegisteredClaims
+	switch c := claims.(type) {
+	case IClaims:
+		rc = c.GetRegisteredClaims()
+	case jwt.MapClaims:
+		var err error
+		if rc, err = toRegisteredClaims(c); err != nil {
+			return code.ErrCodeJwtClaimsInvalid
 		}
+	default:
+		return code.ErrCodeJwtClaimsInvalid
+	}
+
+	cnf := j.getConfig()
+	if err := rc.verify(time.Now(), cnf.Leeway, cnf.Issuer, cnf.Audience); err != nil {
+		return classifyError(err)
+	}
+
+	return code.NoErrCode
+}
+
+// keyFunc method    只接受HMAC签名算法
+func (j *Jwt) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims, code.NoErrCode
+	return j.getSecretKey(), nil
+}
+
+// classifyError function    将jwt校验错误映射为错误码
+func classifyError(err error) code.ErrCode {
+	var ve *jwt.ValidationError
+	if !errors.As(err, &ve) {
+		return code.ErrCodeJwtTokenErr
+	}
+
+	switch {
+	case ve.Errors&jwt.ValidationErrorMalformed != 0:
+		return code.ErrCodeJwtNotEvenAToken
+	case ve.Errors&jwt.ValidationErrorUnverifiable != 0:
+		return code.ErrCodeJwtSigningMethodErr
+	case ve.Errors&jwt.ValidationErrorSignatureInvalid != 0:
+		return code.ErrCodeJwtTokenNotInvalid
+	case ve.Errors&jwt.ValidationErrorExpired != 0:
+		return code.ErrCodeJwtTokenIsExpired
+	case ve.Errors&(jwt.ValidationErrorNotValidYet|jwt.ValidationErrorIssuedAt) != 0:
+		return code.ErrCodeJwtTokenNotActiveYet
+	case ve.Errors&jwt.ValidationErrorIssuer != 0:
+		return code.ErrCodeJwtIssuerInvalid
+	case ve.Errors&jwt.ValidationErrorAudience != 0:
+		return code.ErrCodeJwtAudienceInvalid
+	case ve.Errors&jwt.ValidationErrorClaimsInvalid != 0:
+		return code.ErrCodeJwtClaimsInvalid
+	default:
+		return code.ErrCodeJwtTokenErr
+	}
+}
+
+// getConfig method    获取jwt配置, 未初始化时使用空配置
+func (j *Jwt) getConfig() *JwtConfig {
+	if cnf := GetDefaultJwtConfig(); cnf != nil {
+		return cnf
 	}
 
-	return nil, code.ErrCodeJwtTokenNotActiveYet
+	return &JwtConfig{}
 }
 
 // loadSecretKey method    加载jwt的secretKey
@@ -0,0 +1,131 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestRegisteredClaimsVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	unix := func(d time.Duration) int64 { return now.Add(d).Unix() }
+
+	tests := []struct {
+		name     string
+		claims   RegisteredClaims
+		leeway   time.Duration
+		issuer   string
+		audience []string
+		want     code.ErrCode
+	}{
+		{name: "valid", claims: RegisteredClaims{ExpiresAt: unix(time.Minute), NotBefore: unix(0), IssuedAt: unix(0)}, want: code.NoErrCode},
+		{name: "no time claims", claims: RegisteredClaims{}, want: code.NoErrCode},
+		{name: "expired", claims: RegisteredClaims{ExpiresAt: unix(-time.Second)}, want: code.ErrCodeJwtTokenIsExpired},
+		{name: "expired within leeway", claims: RegisteredClaims{ExpiresAt: unix(-20 * time.Second)}, leeway: 30 * time.Second, want: code.NoErrCode},
+		{name: "expired beyond leeway", claims: RegisteredClaims{ExpiresAt: unix(-40 * time.Second)}, leeway: 30 * time.Second, want: code.ErrCodeJwtTokenIsExpired},
+		{name: "not before", claims: RegisteredClaims{NotBefore: unix(time.Second)}, want: code.ErrCodeJwtTokenNotActiveYet},
+		{name: "not before within leeway", claims: RegisteredClaims{NotBefore: unix(20 * time.Second)}, leeway: 30 * time.Second, want: code.NoErrCode},
+		{name: "issued in future", claims: RegisteredClaims{IssuedAt: unix(time.Minute)}, leeway: 30 * time.Second, want: code.ErrCodeJwtTokenNotActiveYet},
+		{name: "issuer match", claims: RegisteredClaims{Issuer: "ark"}, issuer: "ark", want: code.NoErrCode},
+		{name: "issuer mismatch", claims: RegisteredClaims{Issuer: "other"}, issuer: "ark", want: code.ErrCodeJwtIssuerInvalid},
+		{name: "issuer missing", claims: RegisteredClaims{}, issuer: "ark", want: code.ErrCodeJwtIssuerInvalid},
+		{name: "audience any match", claims: RegisteredClaims{Audience: ClaimStrings{"web", "app"}}, audience: []string{"app"}, want: code.NoErrCode},
+		{name: "audience mismatch", claims: RegisteredClaims{Audience: ClaimStrings{"web"}}, audience: []string{"app"}, want: code.ErrCodeJwtAudienceInvalid},
+		{name: "audience missing", claims: RegisteredClaims{}, audience: []string{"app"}, want: code.ErrCodeJwtAudienceInvalid},
+		{name: "expired wins over issuer", claims: RegisteredClaims{ExpiresAt: unix(-time.Minute)}, issuer: "ark", want: code.ErrCodeJwtTokenIsExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := code.NoErrCode
+			if err := tt.claims.verify(now, tt.leeway, tt.issuer, tt.audience); err != nil {
+				got = classifyError(err)
+			}
+			if got != tt.want {
+				t.Errorf("verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type userClaims struct {
+	RegisteredClaims
+	Uid int64 `json:"uid"`
+}
+
+func TestParse(t *testing.T) {
+	NewCacheOption(WithJwtSigningKeyOption("secret"), WithIssuerOption("ark"),
+		WithAudienceOption("web"), WithLeewayOption(30*time.Second))
+	defer NewCacheOption()
+
+	signer := &Jwt{SigningKey: []byte("secret")}
+	sign := func(t *testing.T, method jwt.SigningMethod, key any, claims jwt.MapClaims) string {
+		t.Helper()
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	now := time.Now()
+
+	valid, errCode := signer.GenerateJwtToken(jwt.MapClaims{"uid": 7})
+	if errCode != code.NoErrCode {
+		t.Fatal(errCode)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  code.ErrCode
+	}{
+		{name: "valid", token: valid, want: code.NoErrCode},
+		{name: "garbage", token: "abc", want: code.ErrCodeJwtNotEvenAToken},
+		{name: "wrong key", token: sign(t, jwt.SigningMethodHS256, []byte("other"),
+			jwt.MapClaims{"iss": "ark", "aud": "web"}), want: code.ErrCodeJwtTokenNotInvalid},
+		{name: "alg none", token: sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType,
+			jwt.MapClaims{"iss": "ark", "aud": "web"}), want: code.ErrCodeJwtSigningMethodErr},
+		{name: "expired within leeway", token: sign(t, jwt.SigningMethodHS256, []byte("secret"),
+			jwt.MapClaims{"iss": "ark", "aud": "web", "exp": now.Add(-10 * time.Second).Unix()}), want: code.NoErrCode},
+		{name: "expired", token: sign(t, jwt.SigningMethodHS256, []byte("secret"),
+			jwt.MapClaims{"iss": "ark", "aud": "web", "exp": now.Add(-time.Minute).Unix()}), want: code.ErrCodeJwtTokenIsExpired},
+		{name: "wrong issuer", token: sign(t, jwt.SigningMethodHS256, []byte("secret"),
+			jwt.MapClaims{"iss": "other", "aud": "web"}), want: code.ErrCodeJwtIssuerInvalid},
+		{name: "aud array", token: sign(t, jwt.SigningMethodHS256, []byte("secret"),
+			jwt.MapClaims{"iss": "ark", "aud": []string{"app", "web"}}), want: code.NoErrCode},
+		{name: "malformed exp", token: sign(t, jwt.SigningMethodHS256, []byte("secret"),
+			jwt.MapClaims{"iss": "ark", "aud": "web", "exp": "soon"}), want: code.ErrCodeJwtClaimsInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := signer.ParseJwtToken(tt.token); got != tt.want {
+				t.Errorf("ParseJwtToken = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("typed claims", func(t *testing.T) {
+		jwtToken = signer
+		defer func() { jwtToken = nil }()
+
+		claims, errCode := ParseClaims[userClaims](valid)
+		if errCode != code.NoErrCode {
+			t.Fatal(errCode)
+		}
+		if claims.Uid != 7 || claims.Issuer != "ark" || len(claims.Audience) != 1 || claims.Audience[0] != "web" {
+			t.Errorf("claims = %+v", claims)
+		}
+	})
+}
+
+func TestClaimsValid(t *testing.T) {
+	claims := &RegisteredClaims{ExpiresAt: time.Now().Add(-time.Second).Unix()}
+	var ve *jwt.ValidationError
+	if err := claims.Valid(); !errors.As(err, &ve) || ve.Errors&jwt.ValidationErrorExpired == 0 {
+		t.Errorf("Valid = %v, want expired", err)
+	}
+}
@@ -1,22 +1,82 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 	"github.com/Anniext/Arkitektur/server/middlewares"
+	"github.com/Anniext/Arkitektur/system/config"
 	"github.com/Anniext/Arkitektur/system/log"
 	"net/http"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 )
 
-func InitDefaultGin(defaultRegister func(*gin.RouterGroup)) error {
-	defaultGin = gin.Default()
+// ErrGinServing InitDefaultGin已经开始监听, 不能再注册路由
+var ErrGinServing = errors.New("server: gin already serving, register routes before InitDefaultGin")
+
+var (
+	engineRegistersMu sync.Mutex
+	engineRegisters   []func(*gin.Engine) // 监听前执行的路由注册回调
+	shutdownHooks     []func()            // 默认gin服务Shutdown时执行的回调
+	ginServing        bool
+)
 
-	defaultGin.Use(middlewares.CorsHandler)
+// RegisterEngine function    登记路由注册回调, 在InitDefaultGin开始监听前按登记顺序执行, 用于挂载/api之外的路由
+//
+// InitDefaultGin之后调用返回ErrGinServing, 监听后再修改路由表会与请求处理并发
+func RegisterEngine(fn func(*gin.Engine)) error {
+	engineRegistersMu.Lock()
+	defer engineRegistersMu.Unlock()
 
-	defaultRegister(defaultGin.Group("/api")) // 注入路由
+	if ginServing {
+		return ErrGinServing
+	}
+	engineRegisters = append(engineRegisters, fn)
+	return nil
+}
+
+// corsHandler function    跨域中间件, 依次使用WithCorsOption、服务配置中的cors, 都没有时使用默认策略
+func corsHandler(cnf *GinConfig) gin.HandlerFunc {
+	if cnf.Cors != nil {
+		return middlewares.Cors(*cnf.Cors)
+	}
+
+	if serverConfig := config.GetServerConfig(); serverConfig != nil && len(serverConfig.CorsInfo.AllowOrigins) > 0 {
+		return middlewares.Cors(middlewares.NewCorsConfig(serverConfig.CorsInfo))
+	}
+
+	return middlewares.CorsHandler
+}
+
+// RegisterOnShutdown function    登记默认gin服务Shutdown时执行的回调, 用于关闭挂载在gin上的长连接
+//
+// InitDefaultGin之后登记的直接注册到http.Server
+func RegisterOnShutdown(fn func()) {
+	engineRegistersMu.Lock()
+	defer engineRegistersMu.Unlock()
+
+	if ginServing {
+		defaultServer.RegisterOnShutdown(fn)
+		return
+	}
+	shutdownHooks = append(shutdownHooks, fn)
+}
+
+func InitDefaultGin(defaultRegister func(*gin.RouterGroup)) error {
+	defaultGin = gin.New()
+	defaultGin.Use(middlewares.RequestId(), middlewares.AccessLog(), middlewares.Recovery())
 
 	cnf := GetDefaultGinConfig()
+	defaultGin.Use(corsHandler(cnf))
+
+	api := defaultGin.Group("/api")
+	defaultRegister(api) // 注入路由
+	RegisterErrCodes(api)
+	if cnf.OpenAPI != nil {
+		RegisterOpenAPI(api, *cnf.OpenAPI)
+	}
+
 	addr := fmt.Sprintf("%s:%d", cnf.Addr, cnf.Port)
 
 	defaultServer = &http.Server{
@@ -24,8 +84,20 @@ func InitDefaultGin(defaultRegister func(*gin.RouterGroup)) error {
 		Handler: defaultGin,
 	}
 
+	engineRegistersMu.Lock()
+	for _, register := range engineRegisters {
+		register(defaultGin)
+	}
+	for _, hook := range shutdownHooks {
+		defaultServer.RegisterOnShutdown(hook)
+	}
+	engineRegisters, shutdownHooks = nil, nil
+	ginServing = true
+	engineRegistersMu.Unlock()
+
+	httpServer := defaultServer
 	go SafeGoRecoverWarpFunc(func() {
-		if err := defaultServer.ListenAndServe(); err != nil {
+		if err := httpServer.ListenAndServe(); err != nil {
 			log.Error("Gin server start err: ", err)
 		}
 	})()
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Anniext/Arkitektur/server/middlewares"
+	"github.com/Anniext/Arkitektur/system/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCorsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	allowOrigin := func(t *testing.T, cnf *GinConfig, origin string) string {
+		t.Helper()
+		engine := gin.New()
+		engine.Use(corsHandler(cnf))
+		engine.GET("/api/ping", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+		req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+		req.Header.Set("Origin", origin)
+		resp := httptest.NewRecorder()
+		engine.ServeHTTP(resp, req)
+		return resp.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	loadConfig := func(t *testing.T, yaml string) {
+		t.Helper()
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "app-test.yaml"), []byte(yaml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		pwd, _ := os.Getwd()
+		rel, _ := filepath.Rel(pwd, dir)
+		if err := config.InitSystemConfig("app", "test", rel); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 服务配置没有cors时使用默认策略
+	loadConfig(t, "cors:\n  allow_origins: []\n")
+	if got := allowOrigin(t, &GinConfig{}, "https://b.com"); got != "*" {
+		t.Fatalf("default allow origin = %q, want *", got)
+	}
+
+	loadConfig(t, "cors:\n  allow_origins: [\"https://a.com\"]\n")
+
+	option := middlewares.DefaultCorsConfig()
+	option.AllowOrigins = []string{"https://c.com"}
+
+	tests := []struct {
+		name   string
+		cnf    *GinConfig
+		origin string
+		want   string
+	}{
+		{name: "server config allows", cnf: &GinConfig{}, origin: "https://a.com", want: "https://a.com"},
+		{name: "server config rejects", cnf: &GinConfig{}, origin: "https://b.com", want: ""},
+		{name: "option wins", cnf: &GinConfig{Cors: &option}, origin: "https://c.com", want: "https://c.com"},
+		{name: "option ignores server config", cnf: &GinConfig{Cors: &option}, origin: "https://a.com", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := allowOrigin(t, tt.cnf, tt.origin); got != tt.want {
+				t.Errorf("allow origin = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitDefaultGinHooks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	NewGinOption(WithAddrOption("127.0.0.1"), WithPortOption(0))
+	t.Cleanup(func() {
+		engineRegistersMu.Lock()
+		ginServing, defaultGin, defaultServer = false, nil, nil
+		engineRegistersMu.Unlock()
+	})
+
+	mounted := make(chan struct{}, 1)
+	if err := RegisterEngine(func(engine *gin.Engine) {
+		mounted <- struct{}{}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	shutdown := make(chan string, 2)
+	RegisterOnShutdown(func() { shutdown <- "before" })
+
+	if err := InitDefaultGin(func(*gin.RouterGroup) {}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-mounted:
+	default:
+		t.Fatal("engine register not run before serving")
+	}
+	if err := RegisterEngine(func(*gin.Engine) {}); !errors.Is(err, ErrGinServing) {
+		t.Errorf("RegisterEngine after serving = %v, want ErrGinServing", err)
+	}
+	RegisterOnShutdown(func() { shutdown <- "after" })
+
+	if err := GetDefaultServer().Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]bool{}
+	for len(got) < 2 {
+		select {
+		case hook := <-shutdown:
+			got[hook] = true
+		case <-time.After(time.Second):
+			t.Fatalf("shutdown hooks run = %v, want before and after", got)
+		}
+	}
+}
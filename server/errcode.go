@@ -0,0 +1,31 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/Anniext/Arkitektur/common"
+	"github.com/gin-gonic/gin"
+)
+
+const EErrCodesPath = "/errcodes" // 相对/api路由组
+
+// RegisterErrCodes function    提供错误码目录导出, format=markdown时返回markdown表格
+func RegisterErrCodes(group *gin.RouterGroup) {
+	group.GET(EErrCodesPath, func(ctx *gin.Context) {
+		api := &common.CodeApi{}
+		registry := code.GetDefaultRegistry()
+
+		if ctx.Query("format") != code.FormatMarkdown {
+			api.Success(ctx, gin.H{"ranges": registry.Ranges(), "codes": registry.Catalogue()})
+			return
+		}
+
+		data, err := registry.Export(code.FormatMarkdown)
+		if err != nil {
+			api.FailError(ctx, err)
+			return
+		}
+		ctx.Data(http.StatusOK, "text/markdown; charset=utf-8", data)
+	})
+}
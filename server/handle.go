@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/Anniext/Arkitektur/common"
+	"github.com/Anniext/Arkitektur/i18n"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+const defaultMultipartMemory = 32 << 20
+
+// HandlerFunc 业务处理函数, 返回NoErrCode时响应resp, 否则响应错误码
+type HandlerFunc[Req any, Resp any] func(ctx *gin.Context, req *Req) (*Resp, code.ErrCode)
+
+// Handle function    泛型处理函数包装, 依次绑定query(form)、请求体(json/form)、路径(uri)参数并校验
+func Handle[Req any, Resp any](handler HandlerFunc[Req, Resp]) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		api := &common.CodeApi{}
+		req := new(Req)
+
+		if err := Bind(ctx, req); err != nil {
+			failInvalidParams(ctx, api, err)
+			return
+		}
+
+		resp, errCode := handler(ctx, req)
+		if ctx.Writer.Written() {
+			return
+		}
+		if errCode != code.NoErrCode {
+			api.Fail(ctx, errCode)
+			return
+		}
+		api.Success(ctx, resp)
+	}
+}
+
+// Bind function    绑定全部来源的参数后统一校验, 避免单一来源校验时误报required
+func Bind(ctx *gin.Context, req any) error {
+	if query := ctx.Request.URL.Query(); len(query) != 0 {
+		if err := binding.MapFormWithTag(req, query, "form"); err != nil {
+			return err
+		}
+	}
+
+	if err := bindBody(ctx, req); err != nil {
+		return err
+	}
+
+	// 路径参数最后绑定, 请求体中的同名字段不能覆盖路由匹配到的参数
+	if len(ctx.Params) != 0 {
+		params := make(map[string][]string, len(ctx.Params))
+		for _, param := range ctx.Params {
+			params[param.Key] = []string{param.Value}
+		}
+		if err := binding.MapFormWithTag(req, params, "uri"); err != nil {
+			return err
+		}
+	}
+
+	GetValidator()
+	return binding.Validator.ValidateStruct(req)
+}
+
+// bindBody function    按Content-Type绑定请求体
+func bindBody(ctx *gin.Context, req any) error {
+	if ctx.Request.Body == nil || ctx.Request.ContentLength == 0 ||
+		ctx.Request.Method == http.MethodGet || ctx.Request.Method == http.MethodHead {
+		return nil
+	}
+
+	switch ctx.ContentType() {
+	case binding.MIMEJSON:
+		err := json.NewDecoder(ctx.Request.Body).Decode(req)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	case binding.MIMEPOSTForm:
+		if err := ctx.Request.ParseForm(); err != nil {
+			return err
+		}
+		return binding.MapFormWithTag(req, ctx.Request.PostForm, "form")
+	case binding.MIMEMultipartPOSTForm:
+		if err := ctx.Request.ParseMultipartForm(defaultMultipartMemory); err != nil {
+			return err
+		}
+		return binding.MapFormWithTag(req, ctx.Request.MultipartForm.Value, "form")
+	}
+
+	return nil
+}
+
+// failInvalidParams function    参数错误时在Data中返回字段级错误
+func failInvalidParams(ctx *gin.Context, api *common.CodeApi, err error) {
+	errCode := code.ErrCodeInvalidParams
+	locale := i18n.Locale(ctx)
+	message := i18n.Message(locale, errCode)
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		api.StatusResult(ctx, http.StatusBadRequest, errCode.Int32(), TranslateValidationErrors(validationErrs, locale), message)
+		return
+	}
+
+	fieldErr := FieldError{Tag: "type", Message: err.Error()}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		fieldErr.Field = typeErr.Field
+	}
+	api.StatusResult(ctx, http.StatusBadRequest, errCode.Int32(), []FieldError{fieldErr}, message)
+}
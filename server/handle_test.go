@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type bindReq struct {
+	ID    string `uri:"id" json:"id" binding:"required"`
+	Name  string `form:"name" json:"name"`
+	Limit int    `form:"limit" json:"limit"`
+}
+
+func TestBindPrecedence(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		target  string
+		body    string
+		want    bindReq
+		wantErr bool
+	}{
+		{
+			name:   "path param wins over body",
+			target: "/items/7",
+			body:   `{"id":"99","name":"body"}`,
+			want:   bindReq{ID: "7", Name: "body"},
+		},
+		{
+			name:   "body wins over query",
+			target: "/items/7?name=query&limit=5",
+			body:   `{"name":"body"}`,
+			want:   bindReq{ID: "7", Name: "body", Limit: 5},
+		},
+		{
+			name:   "query only",
+			target: "/items/7?name=query",
+			want:   bindReq{ID: "7", Name: "query"},
+		},
+		{
+			name:    "type error",
+			target:  "/items/7",
+			body:    `{"limit":"x"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bindReq
+			var err error
+			engine := gin.New()
+			engine.POST("/items/:id", func(ctx *gin.Context) {
+				err = Bind(ctx, &got)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			engine.ServeHTTP(httptest.NewRecorder(), req)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("req = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
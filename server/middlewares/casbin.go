@@ -1,46 +1,193 @@
 package middlewares
 
 import (
+	"net/http"
+	"strings"
+
 	"github.com/Anniext/Arkitektur/casbin"
 	"github.com/Anniext/Arkitektur/code"
 	"github.com/Anniext/Arkitektur/common"
-	"github.com/Anniext/Arkitektur/jwt"
+	"github.com/Anniext/Arkitektur/system/config"
 	"github.com/Anniext/Arkitektur/system/log"
 	"github.com/Anniext/Arkitektur/utils"
+	"github.com/casbin/casbin/v2/util"
 	"github.com/gin-gonic/gin"
-	"strings"
+)
+
+const (
+	ECasbinRolesClaim  = "roles"   // 默认角色列表声明
+	ECasbinRoleClaim   = "role_id" // 兼容旧的单角色声明
+	ECasbinDomainClaim = "domain"  // 多租户模型下默认的域声明
+	ECasbinPrefix      = "/api"    // 默认去掉的路由前缀
 )
 
 var CasbinHandler = Casbin()
 
-func Casbin() gin.HandlerFunc {
+// CasbinConfig struct    casbin中间件配置
+type CasbinConfig struct {
+	RolesClaim   string   // 角色列表声明, 每个角色都作为一个subject校验
+	RoleClaim    string   // 角色列表为空时回退的单角色声明
+	DomainClaim  string   // 多租户模型下的域声明
+	Prefix       string   // object去掉的路由前缀
+	PublicRoutes []string // 免鉴权路由, 格式为 "GET /api/login" 或 "/api/public/*", 支持:param与*
+}
+
+type CasbinOption func(*CasbinConfig)
+
+func WithCasbinRolesClaimOption(rolesClaim string) CasbinOption {
+	return func(c *CasbinConfig) {
+		c.RolesClaim = rolesClaim
+	}
+}
+
+func WithCasbinRoleClaimOption(roleClaim string) CasbinOption {
+	return func(c *CasbinConfig) {
+		c.RoleClaim = roleClaim
+	}
+}
+
+func WithCasbinDomainClaimOption(domainClaim string) CasbinOption {
+	return func(c *CasbinConfig) {
+		c.DomainClaim = domainClaim
+	}
+}
+
+func WithCasbinPrefixOption(prefix string) CasbinOption {
+	return func(c *CasbinConfig) {
+		c.Prefix = prefix
+	}
+}
+
+func WithCasbinPublicRoutesOption(routes ...string) CasbinOption {
+	return func(c *CasbinConfig) {
+		c.PublicRoutes = append(c.PublicRoutes, routes...)
+	}
+}
+
+// CasbinOptionsFromInfo function    将服务配置中的casbin配置转换为中间件Option, 未配置的声明使用默认值
+func CasbinOptionsFromInfo(info config.CasbinInfo) []CasbinOption {
+	options := []CasbinOption{WithCasbinPublicRoutesOption(info.PublicRoutes...)}
+	if info.RolesClaim != "" {
+		options = append(options, WithCasbinRolesClaimOption(info.RolesClaim))
+	}
+	return options
+}
+
+// publicRoute struct    解析后的免鉴权路由
+type publicRoute struct {
+	method string
+	path   string
+}
+
+// parsePublicRoutes function    解析免鉴权路由, 未写方法时匹配全部方法
+func parsePublicRoutes(routes []string) []publicRoute {
+	result := make([]publicRoute, 0, len(routes))
+	for _, route := range routes {
+		fields := strings.Fields(route)
+		switch len(fields) {
+		case 1:
+			result = append(result, publicRoute{path: fields[0]})
+		case 2:
+			result = append(result, publicRoute{method: strings.ToUpper(fields[0]), path: fields[1]})
+		}
+	}
+	return result
+}
+
+// isPublic function    路由模板或请求路径命中免鉴权路由
+func isPublic(routes []publicRoute, method, fullPath, path string) bool {
+	for _, route := range routes {
+		if route.method != "" && route.method != method {
+			continue
+		}
+		if route.path == fullPath || util.KeyMatch2(path, route.path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Casbin function    casbin鉴权中间件, subject为角色列表, object为gin路由模板, 缺少声明时默认拒绝
+func Casbin(options ...CasbinOption) gin.HandlerFunc {
+	cnf := &CasbinConfig{
+		RolesClaim:  ECasbinRolesClaim,
+		RoleClaim:   ECasbinRoleClaim,
+		DomainClaim: ECasbinDomainClaim,
+		Prefix:      ECasbinPrefix,
+	}
+	for _, option := range options {
+		option(cnf)
+	}
+	routes := parsePublicRoutes(cnf.PublicRoutes)
+
 	return func(ctx *gin.Context) {
-		if strings.ToLower(ctx.Request.Method) == "OPTIONS" {
+		method := ctx.Request.Method
+		if method == http.MethodOptions {
 			ctx.Next()
 			return
 		}
 
-		api := &common.CodeApi{}
-		roleId := jwt.GetTokenData[int64](ctx, "role_id")
-		if roleId == 0 {
-			log.Errorln("无法使用 `Casbin` 权限校验, 请确保 `Token` 中包含了 `role_id`")
+		fullPath := ctx.FullPath()
+		if isPublic(routes, method, fullPath, ctx.Request.URL.Path) {
 			ctx.Next()
 			return
 		}
 
-		path := utils.ConvertToRestfulURL(strings.TrimPrefix(ctx.Request.URL.Path, "/api"))
-
-		success, err := casbin.GetDefaultCasbin().Enforce(utils.Int64ToString(roleId), path, ctx.Request.Method)
-		if err != nil {
+		api := &common.CodeApi{}
+		enforcer := casbin.GetDefaultCasbin()
+		if enforcer == nil {
+			ctx.Abort()
 			api.Fail(ctx, code.ErrCodeCasbinNotActiveYet)
 			return
 		}
-		if success {
-			ctx.Next()
-		} else {
+
+		claims, ok := ctx.Value("claims").(map[string]any)
+		if !ok {
+			ctx.Abort()
+			api.UnauthorizedResult(ctx, code.ErrCodeJwtNotEvenAToken.Int32(), nil, code.ErrCodeJwtNotEvenAToken.String())
+			return
+		}
+
+		roles := utils.GetMapStrings(claims, cnf.RolesClaim)
+		if len(roles) == 0 {
+			roles = utils.GetMapStrings(claims, cnf.RoleClaim)
+		}
+		if len(roles) == 0 {
+			log.Errorf("casbin deny %s %s: token中没有角色声明 `%s`", method, ctx.Request.URL.Path, cnf.RolesClaim)
 			ctx.Abort()
 			api.Fail(ctx, code.ErrCodeCasbinNotPermissions)
 			return
 		}
+
+		if fullPath == "" {
+			fullPath = utils.ConvertToRestfulURL(ctx.Request.URL.Path)
+		}
+		obj := strings.TrimPrefix(fullPath, cnf.Prefix)
+
+		var domain []any
+		if casbin.IsDomainModel(enforcer) {
+			domain = []any{""}
+			if values := utils.GetMapStrings(claims, cnf.DomainClaim); len(values) != 0 {
+				domain[0] = values[0]
+			}
+		}
+
+		for _, role := range roles {
+			request := append(append([]any{role}, domain...), obj, method)
+			success, err := enforcer.Enforce(request...)
+			if err != nil {
+				log.Errorf("casbin enforce %v err: %v", request, err)
+				ctx.Abort()
+				api.Fail(ctx, code.ErrCodeCasbinNotActiveYet)
+				return
+			}
+			if success {
+				ctx.Next()
+				return
+			}
+		}
+
+		ctx.Abort()
+		api.Fail(ctx, code.ErrCodeCasbinNotPermissions)
 	}
 }
@@ -0,0 +1,138 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Anniext/Arkitektur/casbin"
+	"github.com/Anniext/Arkitektur/system/config"
+	"github.com/Anniext/Arkitektur/system/log"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	// 拒绝时会写system/log
+	log.NewSlogCore(log.NewSlogOption(log.WithModeOption("dev")))
+	m.Run()
+}
+
+func initTestCasbin(t *testing.T, domain bool, lines ...[]string) {
+	t.Helper()
+
+	casbin.NewCacheOption(casbin.WithDomainOption(domain), casbin.WithCustomAdapterOption(casbin.NewMemoryAdapter(lines...)))
+	if err := casbin.InitCasbin(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// casbinEngine function    在/api分组下挂载casbin中间件, claims为nil时不写入声明
+func casbinEngine(claims map[string]any, options ...CasbinOption) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	api := engine.Group("/api", func(ctx *gin.Context) {
+		if claims != nil {
+			ctx.Set("claims", claims)
+		}
+	}, Casbin(options...))
+
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+	api.GET("/user/:id", ok)
+	api.POST("/login", ok)
+	api.GET("/login", ok)
+	api.GET("/public/*path", ok)
+	api.OPTIONS("/user/:id", ok)
+	return engine
+}
+
+func TestCasbin(t *testing.T) {
+	initTestCasbin(t, false,
+		[]string{"p", "admin", "/user/:id", "GET"},
+		[]string{"p", "1", "/user/:id", "GET"},
+	)
+	public := CasbinOptionsFromInfo(config.CasbinInfo{PublicRoutes: []string{"POST /api/login", "/api/public/*"}})
+
+	tests := []struct {
+		name   string
+		claims map[string]any
+		method string
+		path   string
+		want   int
+	}{
+		{name: "missing claims", method: http.MethodGet, path: "/api/user/7", want: http.StatusUnauthorized},
+		{name: "no roles claim", claims: map[string]any{"uid": 7}, method: http.MethodGet, path: "/api/user/7", want: http.StatusForbidden},
+		{name: "roles claim", claims: map[string]any{"roles": []any{"guest", "admin"}}, method: http.MethodGet, path: "/api/user/7", want: http.StatusOK},
+		{name: "role denied", claims: map[string]any{"roles": []any{"guest"}}, method: http.MethodGet, path: "/api/user/7", want: http.StatusForbidden},
+		{name: "fallback to role_id", claims: map[string]any{"role_id": float64(1)}, method: http.MethodGet, path: "/api/user/7", want: http.StatusOK},
+		{name: "public method and path", method: http.MethodPost, path: "/api/login", want: http.StatusOK},
+		{name: "public method mismatch", method: http.MethodGet, path: "/api/login", want: http.StatusUnauthorized},
+		{name: "public keyMatch2", method: http.MethodGet, path: "/api/public/docs/index.html", want: http.StatusOK},
+		{name: "options passthrough", method: http.MethodOptions, path: "/api/user/7", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			casbinEngine(tt.claims, public...).ServeHTTP(resp, httptest.NewRequest(tt.method, tt.path, nil))
+			if resp.Code != tt.want {
+				t.Errorf("status = %d, want %d", resp.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestCasbinObjectPrefix(t *testing.T) {
+	// 策略写的是去掉/api前缀的路由模板, 不是请求路径
+	initTestCasbin(t, false, []string{"p", "admin", "/user/:id", "GET"}, []string{"p", "editor", "/api/user/:id", "GET"})
+
+	tests := []struct {
+		name    string
+		role    string
+		options []CasbinOption
+		want    int
+	}{
+		{name: "template without prefix", role: "admin", want: http.StatusOK},
+		{name: "template with prefix", role: "editor", want: http.StatusForbidden},
+		{name: "prefix disabled", role: "editor", options: []CasbinOption{WithCasbinPrefixOption("")}, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			engine := casbinEngine(map[string]any{"roles": []any{tt.role}}, tt.options...)
+			engine.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/user/7", nil))
+			if resp.Code != tt.want {
+				t.Errorf("status = %d, want %d", resp.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestCasbinDomain(t *testing.T) {
+	initTestCasbin(t, true,
+		[]string{"p", "admin", "t1", "/user/:id", "GET"},
+		[]string{"p", "admin", "", "/user/:id", "GET"},
+		[]string{"p", "guest", "", "/user/:id", "GET"},
+	)
+
+	tests := []struct {
+		name   string
+		claims map[string]any
+		want   int
+	}{
+		{name: "domain claim", claims: map[string]any{"roles": []any{"admin"}, "domain": "t1"}, want: http.StatusOK},
+		{name: "other domain", claims: map[string]any{"roles": []any{"admin"}, "domain": "t2"}, want: http.StatusForbidden},
+		{name: "no domain claim uses empty domain", claims: map[string]any{"roles": []any{"guest"}}, want: http.StatusOK},
+		{name: "no domain claim denied", claims: map[string]any{"roles": []any{"editor"}}, want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			casbinEngine(tt.claims).ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/user/7", nil))
+			if resp.Code != tt.want {
+				t.Errorf("status = %d, want %d", resp.Code, tt.want)
+			}
+		})
+	}
+}
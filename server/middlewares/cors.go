@@ -2,31 +2,225 @@ package middlewares
 
 import (
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Anniext/Arkitektur/system/config"
+	"github.com/Anniext/Arkitektur/utils"
+	"github.com/casbin/casbin/v2/util"
 	"github.com/gin-gonic/gin"
 )
 
-var CorsHandler = Cross()
+var (
+	DefaultCorsMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodHead, http.MethodOptions}
+	DefaultCorsHeaders = []string{"Authorization", "Content-Type", "Accept", "Accept-Language", "Origin",
+		"X-Requested-With", "X-Request-ID", "X-CSRF-Token", "Token", "Channel", "Uid", "Cache-Control"}
+	DefaultCorsExposeHeaders = []string{"Content-Length", "Content-Language", "Content-Type", "Content-Disposition",
+		"Expires", "Last-Modified", "X-Request-ID", "New-Token", "New-Expires-At"}
+)
+
+var CorsHandler = Cors(DefaultCorsConfig())
+
+// CorsConfig struct    跨域策略
+type CorsConfig struct {
+	AllowOrigins     []string      // 精确origin、"*.example.com"、"https://*.example.com", "*"表示全部
+	AllowMethods     []string      // 预检允许的方法
+	AllowHeaders     []string      // 预检允许的请求头, 为空时回显预检请求的头
+	ExposeHeaders    []string      // 暴露给浏览器的响应头
+	AllowCredentials bool          // 允许携带cookie, 此时不会返回 "*"
+	MaxAge           time.Duration // 预检缓存时间
+	Routes           []CorsRoute   // 按路由覆盖, 先声明的优先
+}
+
+// CorsRoute struct    单独的路由跨域策略, Path支持:param与*
+type CorsRoute struct {
+	Path string
+	CorsConfig
+}
+
+// DefaultCorsConfig function    默认允许全部origin且不携带凭证
+func DefaultCorsConfig() CorsConfig {
+	return CorsConfig{
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  DefaultCorsMethods,
+		AllowHeaders:  DefaultCorsHeaders,
+		ExposeHeaders: DefaultCorsExposeHeaders,
+		MaxAge:        12 * time.Hour,
+	}
+}
+
+// NewCorsConfig function    从服务配置生成跨域策略, 未配置的列表使用默认值, 路由覆盖只替换配置了的字段
+func NewCorsConfig(info config.CorsInfo) CorsConfig {
+	cnf := corsFromInfo(info)
+	for _, route := range info.Routes {
+		cnf.Routes = append(cnf.Routes, CorsRoute{Path: route.Path, CorsConfig: overlayCors(cnf, route)})
+	}
+	return cnf
+}
+
+// overlayCors function    以全局策略为基础叠加路由配置
+func overlayCors(parent CorsConfig, route config.CorsRouteInfo) CorsConfig {
+	cnf := parent
+	cnf.Routes = nil
+	if len(route.AllowOrigins) > 0 {
+		cnf.AllowOrigins = route.AllowOrigins
+	}
+	if len(route.AllowMethods) > 0 {
+		cnf.AllowMethods = route.AllowMethods
+	}
+	if len(route.AllowHeaders) > 0 {
+		cnf.AllowHeaders = route.AllowHeaders
+	}
+	if len(route.ExposeHeaders) > 0 {
+		cnf.ExposeHeaders = route.ExposeHeaders
+	}
+	if route.AllowCredentials != nil {
+		cnf.AllowCredentials = *route.AllowCredentials
+	}
+	if route.MaxAge > 0 {
+		cnf.MaxAge = time.Duration(route.MaxAge) * time.Second
+	}
+	return cnf
+}
+
+// corsFromInfo function    转换单条跨域配置
+func corsFromInfo(info config.CorsInfo) CorsConfig {
+	cnf := CorsConfig{
+		AllowOrigins:     info.AllowOrigins,
+		AllowMethods:     info.AllowMethods,
+		AllowHeaders:     info.AllowHeaders,
+		ExposeHeaders:    info.ExposeHeaders,
+		AllowCredentials: info.AllowCredentials,
+		MaxAge:           time.Duration(info.MaxAge) * time.Second,
+	}
+	if len(cnf.AllowMethods) == 0 {
+		cnf.AllowMethods = DefaultCorsMethods
+	}
+	if len(cnf.AllowHeaders) == 0 {
+		cnf.AllowHeaders = DefaultCorsHeaders
+	}
+	if len(cnf.ExposeHeaders) == 0 {
+		cnf.ExposeHeaders = DefaultCorsExposeHeaders
+	}
+	return cnf
+}
+
+// corsPolicy struct    预先拼接好的响应头
+type corsPolicy struct {
+	allowOrigins     []string
+	allowAll         bool
+	allowMethods     string
+	allowHeaders     string
+	exposeHeaders    string
+	allowCredentials bool
+	maxAge           string
+}
+
+// newCorsPolicy function    预处理跨域策略
+func newCorsPolicy(cnf CorsConfig) *corsPolicy {
+	p := &corsPolicy{
+		allowOrigins:     cnf.AllowOrigins,
+		allowAll:         slices.Contains(cnf.AllowOrigins, "*"),
+		allowMethods:     strings.ToUpper(strings.Join(cnf.AllowMethods, ", ")),
+		allowHeaders:     strings.Join(cnf.AllowHeaders, ", "),
+		exposeHeaders:    strings.Join(cnf.ExposeHeaders, ", "),
+		allowCredentials: cnf.AllowCredentials,
+	}
+	if cnf.MaxAge > 0 {
+		p.maxAge = strconv.FormatInt(int64(cnf.MaxAge/time.Second), 10)
+	}
+	return p
+}
+
+// handle method    写入跨域响应头, 返回false表示origin不被允许
+func (p *corsPolicy) handle(ctx *gin.Context, origin string, preflight bool) bool {
+	header := ctx.Writer.Header()
+	header.Add("Vary", "Origin")
+	if preflight {
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+	}
+
+	if !utils.MatchOrigin(origin, p.allowOrigins) {
+		return false
+	}
+
+	// 浏览器不接受 "*" 与凭证同时出现, 携带凭证时回显origin
+	if p.allowAll && !p.allowCredentials {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.allowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if !preflight {
+		if p.exposeHeaders != "" {
+			header.Set("Access-Control-Expose-Headers", p.exposeHeaders)
+		}
+		return true
+	}
+
+	header.Set("Access-Control-Allow-Methods", p.allowMethods)
+	if p.allowHeaders != "" {
+		header.Set("Access-Control-Allow-Headers", p.allowHeaders)
+	} else if requestHeaders := ctx.GetHeader("Access-Control-Request-Headers"); requestHeaders != "" {
+		header.Set("Access-Control-Allow-Headers", requestHeaders)
+	}
+	if p.maxAge != "" {
+		header.Set("Access-Control-Max-Age", p.maxAge)
+	}
+	return true
+}
+
+// Cors function    跨域中间件, 预检请求在此处直接返回, 不会进入后续处理
+func Cors(cnf CorsConfig) gin.HandlerFunc {
+	policy := newCorsPolicy(cnf)
+	paths := make([]string, 0, len(cnf.Routes))
+	policies := make([]*corsPolicy, 0, len(cnf.Routes))
+	for _, route := range cnf.Routes {
+		paths = append(paths, route.Path)
+		policies = append(policies, newCorsPolicy(route.CorsConfig))
+	}
 
-func Cross() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		method := ctx.Request.Method
-		ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		ctx.Header("Access-Control-Allow-Origin", "*")
-		ctx.Header("Access-Control-Allow-Headers", "Authorization,Channel, Uid, Content-Length, X-CSRF-Token,"+
-			" Token,session,X_Requested_With,Accept, Origin, Host, Connection, Accept-Encoding, Accept-Language,DNT, "+
-			"X-CustomHeader, Keep-Alive, User-Agent, X-Requested-With, If-Modified-Since, Cache-Control, Content-Type")
-		ctx.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, UPDATE")
-		ctx.Header("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin, "+
-			"Access-Control-Allow-Headers, Content-Language, Content-Type, Expires, Last-Modified, New-Token, "+
-			"New-Expires-At")
-		ctx.Header("Access-Control-Allow-Credentials", "true")
-		ctx.Set("content-type", "application/json")
-
-		if method == "OPTIONS" {
-			ctx.AbortWithStatus(http.StatusNoContent)
+		origin := ctx.GetHeader("Origin")
+		if origin == "" {
+			ctx.Next()
+			return
+		}
+
+		p := policy
+		for i, path := range paths {
+			if util.KeyMatch2(ctx.Request.URL.Path, path) {
+				p = policies[i]
+				break
+			}
+		}
+
+		preflight := ctx.Request.Method == http.MethodOptions && ctx.GetHeader("Access-Control-Request-Method") != ""
+		allowed := p.handle(ctx, origin, preflight)
+
+		if preflight {
+			if allowed {
+				ctx.AbortWithStatus(http.StatusNoContent)
+			} else {
+				ctx.AbortWithStatus(http.StatusForbidden)
+			}
+			return
 		}
 
 		ctx.Next()
 	}
 }
+
+// Cross function    兼容旧名称
+//
+// Deprecated: 使用 Cors
+func Cross() gin.HandlerFunc {
+	return Cors(DefaultCorsConfig())
+}
@@ -0,0 +1,151 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Anniext/Arkitektur/system/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewCorsConfigRouteInherits(t *testing.T) {
+	allow := false
+	cnf := NewCorsConfig(config.CorsInfo{
+		AllowOrigins:     []string{"https://a.com"},
+		AllowHeaders:     []string{"X-Token"},
+		AllowCredentials: true,
+		MaxAge:           60,
+		Routes: []config.CorsRouteInfo{
+			{Path: "/public/*", AllowOrigins: []string{"*"}, AllowCredentials: &allow},
+			{Path: "/admin/*", MaxAge: 10},
+		},
+	})
+
+	public := cnf.Routes[0].CorsConfig
+	want := CorsConfig{
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  DefaultCorsMethods,
+		AllowHeaders:  []string{"X-Token"},
+		ExposeHeaders: DefaultCorsExposeHeaders,
+		MaxAge:        time.Minute,
+	}
+	if !reflect.DeepEqual(public, want) {
+		t.Errorf("public route = %+v, want %+v", public, want)
+	}
+
+	admin := cnf.Routes[1].CorsConfig
+	want = CorsConfig{
+		AllowOrigins:     []string{"https://a.com"},
+		AllowMethods:     DefaultCorsMethods,
+		AllowHeaders:     []string{"X-Token"},
+		ExposeHeaders:    DefaultCorsExposeHeaders,
+		AllowCredentials: true,
+		MaxAge:           10 * time.Second,
+	}
+	if !reflect.DeepEqual(admin, want) {
+		t.Errorf("admin route = %+v, want %+v", admin, want)
+	}
+}
+
+func TestCors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cnf := CorsConfig{
+		AllowOrigins:     []string{"https://a.com", "*.b.com"},
+		AllowMethods:     []string{"get", "post"},
+		AllowHeaders:     []string{"X-Token"},
+		ExposeHeaders:    []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           time.Minute,
+		Routes: []CorsRoute{
+			{Path: "/public/*", CorsConfig: CorsConfig{AllowOrigins: []string{"*"}, AllowMethods: []string{"GET"}}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		origin    string
+		preflight bool
+		status    int
+		headers   map[string]string
+	}{
+		{
+			name:   "no origin passes through",
+			method: http.MethodGet, path: "/api", status: http.StatusOK,
+			headers: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "allowed origin echoed with credentials",
+			method: http.MethodGet, path: "/api", origin: "https://a.com", status: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":      "https://a.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Request-ID",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:   "disallowed origin gets no headers",
+			method: http.MethodGet, path: "/api", origin: "https://c.com", status: http.StatusOK,
+			headers: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "preflight allowed",
+			method: http.MethodOptions, path: "/api", origin: "https://x.b.com", preflight: true, status: http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":  "https://x.b.com",
+				"Access-Control-Allow-Methods": "GET, POST",
+				"Access-Control-Allow-Headers": "X-Token",
+				"Access-Control-Max-Age":       "60",
+			},
+		},
+		{
+			name:   "preflight disallowed",
+			method: http.MethodOptions, path: "/api", origin: "https://c.com", preflight: true, status: http.StatusForbidden,
+			headers: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "route override allows any origin",
+			method: http.MethodGet, path: "/public/file", origin: "https://c.com", status: http.StatusOK,
+			headers: map[string]string{"Access-Control-Allow-Origin": "*", "Access-Control-Allow-Credentials": ""},
+		},
+		{
+			name:   "route preflight echoes request headers",
+			method: http.MethodOptions, path: "/public/file", origin: "https://c.com", preflight: true, status: http.StatusNoContent,
+			headers: map[string]string{"Access-Control-Allow-Headers": "X-Custom", "Access-Control-Allow-Methods": "GET"},
+		},
+	}
+
+	engine := gin.New()
+	engine.Use(Cors(cnf))
+	engine.Any("/*path", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+				req.Header.Set("Access-Control-Request-Headers", "X-Custom")
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			for key, want := range tt.headers {
+				if got := w.Header().Get(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
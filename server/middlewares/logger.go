@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/Anniext/Arkitektur/jwt"
+	"github.com/Anniext/Arkitektur/system/log"
+	"github.com/Anniext/Arkitektur/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const EAccessLogUidClaim = "uid" // 访问日志记录的用户id声明
+
+// AccessLog function    通过system/log记录访问日志, 4xx记为warn, 5xx记为error
+func AccessLog() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		userAgent := ctx.Request.UserAgent()
+		status := ctx.Writer.Status()
+		fields := []zap.Field{
+			zap.String("requestId", GetRequestId(ctx)),
+			zap.String("method", ctx.Request.Method),
+			zap.String("path", ctx.Request.URL.Path),
+			zap.String("route", ctx.FullPath()),
+			zap.String("query", ctx.Request.URL.RawQuery),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.Int("size", ctx.Writer.Size()),
+			zap.String("ip", utils.GetClientIP(ctx)),
+			zap.String("platform", utils.GetPlatform(userAgent)),
+			zap.String("browser", utils.GetBrowser(userAgent)),
+			zap.Int64("uid", jwt.GetTokenData[int64](ctx, EAccessLogUidClaim)),
+		}
+		if len(ctx.Errors) != 0 {
+			fields = append(fields, zap.String("errors", ctx.Errors.String()))
+		}
+
+		logger := log.GetLogger()
+		switch {
+		case status >= 500:
+			logger.Error("access", fields...)
+		case status >= 400:
+			logger.Warn("access", fields...)
+		default:
+			logger.Info("access", fields...)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"errors"
+	"net"
+	"os"
+	"runtime/debug"
+	"syscall"
+
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/Anniext/Arkitektur/common"
+	"github.com/Anniext/Arkitektur/system/log"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Recovery function    捕获处理函数panic, 通过CodeApi返回ErrCodeOfflineReasonHandlerPanic
+func Recovery() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+
+			log.GetLogger().Error("panic recovered",
+				zap.String("requestId", GetRequestId(ctx)),
+				zap.String("method", ctx.Request.Method),
+				zap.String("path", ctx.Request.URL.Path),
+				zap.Any("error", err),
+				zap.ByteString("stack", debug.Stack()),
+			)
+
+			// 客户端已断开时无法再写入响应
+			if e, ok := err.(error); ok && isBrokenPipe(e) {
+				ctx.Abort()
+				return
+			}
+
+			ctx.Abort()
+			if !ctx.Writer.Written() {
+				api := &common.CodeApi{}
+				api.Fail(ctx, code.ErrCodeOfflineReasonHandlerPanic)
+			}
+		}()
+
+		ctx.Next()
+	}
+}
+
+// isBrokenPipe function    判断是否为连接被客户端断开
+func isBrokenPipe(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+
+	var syscallErr *os.SyscallError
+	if errors.As(opErr, &syscallErr) {
+		return errors.Is(syscallErr.Err, syscall.EPIPE) || errors.Is(syscallErr.Err, syscall.ECONNRESET)
+	}
+	return false
+}
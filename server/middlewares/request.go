@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ERequestIdHeader     = "X-Request-ID"     // 请求id请求头
+	ERequestIdKey        = "requestId"        // 请求id在gin上下文中的key
+	ERequestStartTimeKey = "requestStartTime" // 请求开始时间(UnixMicro), common.CodeApi据此统计耗时
+	maxRequestIdLength   = 128
+)
+
+// RequestId function    生成或透传X-Request-ID, 并记录请求开始时间
+func RequestId() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set(ERequestStartTimeKey, time.Now().UnixMicro())
+
+		requestId := ctx.GetHeader(ERequestIdHeader)
+		if requestId == "" || len(requestId) > maxRequestIdLength {
+			requestId = newRequestId()
+		}
+
+		ctx.Set(ERequestIdKey, requestId)
+		ctx.Header(ERequestIdHeader, requestId)
+		ctx.Next()
+	}
+}
+
+// GetRequestId function    获取当前请求id
+func GetRequestId(ctx *gin.Context) string {
+	return ctx.GetString(ERequestIdKey)
+}
+
+// newRequestId function    生成32位十六进制请求id
+func newRequestId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,451 @@
+package server
+
+import (
+	_ "embed"
+	"html"
+	"io/fs"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+const (
+	EOpenAPIPath    = "/openapi.json" // 相对/api路由组
+	ESwaggerUIPath  = "/docs"         // 相对/api路由组
+	ESwaggerUIAsset = "/docs/assets"  // 内置Swagger UI静态资源, 相对/api路由组
+	openAPIVersion  = "3.0.3"
+	codeApiSchema   = "CodeApi"
+	schemaRefPrefix = "#/components/schemas/"
+)
+
+//go:embed swagger.html
+var swaggerHTML string
+
+// swaggerAssets 页面用到的内置静态资源, 来自swaggo/files打包的swagger-ui-dist
+var swaggerAssets = map[string]string{
+	"swagger-ui.css":       "text/css; charset=utf-8",
+	"swagger-ui-bundle.js": "text/javascript; charset=utf-8",
+}
+
+// OpenAPIInfo struct    文档基本信息
+type OpenAPIInfo struct {
+	Title       string
+	Version     string
+	Description string
+	AssetURL    string // swagger-ui-dist静态资源地址, 为空时使用内置资源, 不依赖外网
+}
+
+// RouteDoc struct    路由注解, Request与Response为类型的零值, 例如 UserReq{}
+type RouteDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Request     any
+	Response    any
+	ErrCodes    []code.ErrCode // 可能返回的错误码
+}
+
+// routeSpec struct    已注册的路由文档
+type routeSpec struct {
+	method string
+	path   string
+	doc    RouteDoc
+}
+
+var (
+	routeSpecsMu sync.RWMutex
+	routeSpecs   []*routeSpec
+)
+
+// Document function    登记路由文档, path与注册到group上的路由一致
+func Document(group *gin.RouterGroup, method, path string, doc RouteDoc) {
+	routeSpecsMu.Lock()
+	defer routeSpecsMu.Unlock()
+
+	routeSpecs = append(routeSpecs, &routeSpec{
+		method: strings.ToLower(method),
+		path:   joinPath(group.BasePath(), path),
+		doc:    doc,
+	})
+}
+
+// HandleDoc function    注册泛型处理函数并登记文档, 请求与响应类型取自处理函数
+func HandleDoc[Req any, Resp any](group *gin.RouterGroup, method, path string, handler HandlerFunc[Req, Resp], doc RouteDoc) {
+	doc.Request = *new(Req)
+	doc.Response = *new(Resp)
+	Document(group, method, path, doc)
+	group.Handle(method, path, Handle(handler))
+}
+
+// RegisterOpenAPI function    在路由组上提供openapi.json、Swagger UI页面与内置的静态资源
+func RegisterOpenAPI(group *gin.RouterGroup, info OpenAPIInfo) {
+	specPath := joinPath(group.BasePath(), EOpenAPIPath)
+	assetURL := info.AssetURL
+	if assetURL == "" {
+		assetURL = joinPath(group.BasePath(), ESwaggerUIAsset)
+	}
+
+	group.GET(EOpenAPIPath, func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, BuildOpenAPI(info))
+	})
+	group.GET(ESwaggerUIPath, func(ctx *gin.Context) {
+		page := strings.ReplaceAll(swaggerHTML, "{{title}}", html.EscapeString(info.Title))
+		page = strings.ReplaceAll(page, "{{assets}}", html.EscapeString(strings.TrimSuffix(assetURL, "/")))
+		page = strings.ReplaceAll(page, "{{spec}}", specPath)
+		ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+	})
+	group.GET(ESwaggerUIAsset+"/:file", func(ctx *gin.Context) {
+		name := ctx.Param("file")
+		contentType, ok := swaggerAssets[name]
+		if !ok {
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+
+		data, err := fs.ReadFile(swaggerFiles.FS, name)
+		if err != nil {
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		ctx.Header("Cache-Control", "public, max-age=86400")
+		ctx.Data(http.StatusOK, contentType, data)
+	})
+}
+
+// BuildOpenAPI function    根据已登记的路由生成OpenAPI 3文档
+func BuildOpenAPI(info OpenAPIInfo) map[string]any {
+	routeSpecsMu.RLock()
+	specs := append([]*routeSpec(nil), routeSpecs...)
+	routeSpecsMu.RUnlock()
+
+	g := &schemaGenerator{
+		schemas: map[string]any{codeApiSchema: codeApiObject()},
+		names:   make(map[reflect.Type]string),
+	}
+
+	paths := make(map[string]map[string]any)
+	for _, spec := range specs {
+		path := ginPathToOpenAPI(spec.path)
+		if paths[path] == nil {
+			paths[path] = make(map[string]any)
+		}
+		paths[path][spec.method] = g.operation(spec)
+	}
+
+	return map[string]any{
+		"openapi": openAPIVersion,
+		"info": map[string]any{
+			"title":       info.Title,
+			"version":     info.Version,
+			"description": info.Description,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": g.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+		"security": []any{map[string]any{"bearerAuth": []string{}}},
+	}
+}
+
+// codeApiObject function    common.CodeApi响应信封
+func codeApiObject() map[string]any {
+	return map[string]any{
+		"type":     "object",
+		"required": []string{"code", "message", "data", "nowTime"},
+		"properties": map[string]any{
+			"code":    map[string]any{"type": "integer", "format": "int32", "description": "错误码, 200表示成功"},
+			"message": map[string]any{"type": "string", "description": "错误码对应的提示"},
+			"data":    map[string]any{"description": "业务数据, 参数错误时为字段错误列表", "nullable": true},
+			"nowTime": map[string]any{"type": "integer", "format": "int64", "description": "服务器时间(秒)"},
+			"useTime": map[string]any{"type": "string", "description": "请求耗时(秒)"},
+		},
+	}
+}
+
+// schemaGenerator struct    反射生成schema, 命名结构体放入components
+type schemaGenerator struct {
+	schemas map[string]any
+	names   map[reflect.Type]string // 已分配的components名称
+}
+
+// operation method    生成单个路由的operation
+func (g *schemaGenerator) operation(spec *routeSpec) map[string]any {
+	doc := spec.doc
+	op := map[string]any{
+		"summary":     doc.Summary,
+		"description": doc.Description,
+		"operationId": spec.method + schemaNameReplacer.ReplaceAllString(spec.path, "_"),
+	}
+	if len(doc.Tags) != 0 {
+		op["tags"] = doc.Tags
+	}
+
+	if params := g.parameters(doc.Request); len(params) != 0 {
+		op["parameters"] = params
+	}
+	if spec.method != "get" && spec.method != "head" && spec.method != "delete" {
+		if body := g.body(doc.Request); body != nil {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": body}},
+			}
+		}
+	}
+
+	envelope := map[string]any{"$ref": schemaRefPrefix + codeApiSchema}
+	if doc.Response != nil {
+		envelope = map[string]any{"allOf": []any{
+			envelope,
+			map[string]any{"type": "object", "properties": map[string]any{"data": g.schema(reflect.TypeOf(doc.Response))}},
+		}}
+	}
+
+	responses := map[string]any{
+		"200": map[string]any{
+			"description": "成功时code为200",
+			"content":     map[string]any{"application/json": map[string]any{"schema": envelope}},
+		},
+	}
+
+	// 错误码按code.HTTPStatus分组到对应的HTTP状态
+	errCodes := make([]map[string]any, 0, len(doc.ErrCodes))
+	for _, errCode := range doc.ErrCodes {
+		status := strconv.Itoa(code.HTTPStatus(errCode))
+		line := "- " + strconv.Itoa(int(errCode.Int32())) + ": " + errCode.String()
+		if response, ok := responses[status].(map[string]any); ok && status != "200" {
+			response["description"] = response["description"].(string) + "\n" + line
+		} else if !ok {
+			responses[status] = map[string]any{
+				"description": line,
+				"content": map[string]any{"application/json": map[string]any{
+					"schema": map[string]any{"$ref": schemaRefPrefix + codeApiSchema},
+				}},
+			}
+		}
+		errCodes = append(errCodes, map[string]any{"code": errCode.Int32(), "message": errCode.String()})
+	}
+	if len(errCodes) != 0 {
+		op["x-error-codes"] = errCodes
+	}
+
+	op["responses"] = responses
+	return op
+}
+
+// parameters method    uri标签生成path参数, form标签生成query参数
+func (g *schemaGenerator) parameters(request any) []any {
+	params := make([]any, 0)
+	t := indirectType(reflect.TypeOf(request))
+	if t == nil || t.Kind() != reflect.Struct {
+		return params
+	}
+
+	for _, field := range structFields(t) {
+		required := strings.Contains(field.Tag.Get("binding"), "required")
+		if name, _, _ := strings.Cut(field.Tag.Get("uri"), ","); name != "" && name != "-" {
+			params = append(params, map[string]any{
+				"name": name, "in": "path", "required": true, "schema": g.schema(field.Type),
+			})
+		}
+		if name, _, _ := strings.Cut(field.Tag.Get("form"), ","); name != "" && name != "-" {
+			params = append(params, map[string]any{
+				"name": name, "in": "query", "required": required, "schema": g.schema(field.Type),
+				"description": field.Tag.Get("description"),
+			})
+		}
+	}
+	return params
+}
+
+// body method    json标签生成请求体, 没有json字段时返回nil
+func (g *schemaGenerator) body(request any) map[string]any {
+	t := indirectType(reflect.TypeOf(request))
+	if t == nil {
+		return nil
+	}
+	if t.Kind() != reflect.Struct {
+		return g.schema(t)
+	}
+
+	for _, field := range structFields(t) {
+		if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+			return g.object(t, true)
+		}
+	}
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema method    生成类型的schema
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	t = indirectType(t)
+	if t == nil {
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32:
+		return map[string]any{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]any{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]any{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return g.object(t, false)
+		}
+
+		name := g.schemaName(t)
+		if _, ok := g.schemas[name]; !ok {
+			g.schemas[name] = map[string]any{} // 先占位, 防止递归类型死循环
+			g.schemas[name] = g.object(t, false)
+		}
+		return map[string]any{"$ref": schemaRefPrefix + name}
+	default:
+		return map[string]any{}
+	}
+}
+
+// object method    生成结构体的json对象schema, bodyOnly时跳过只用于path、query的字段
+func (g *schemaGenerator) object(t reflect.Type, bodyOnly bool) map[string]any {
+	properties := make(map[string]any)
+	required := make([]string, 0)
+
+	for _, field := range structFields(t) {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			if bodyOnly && (field.Tag.Get("uri") != "" || field.Tag.Get("form") != "") {
+				continue
+			}
+			name = field.Name
+		}
+
+		property := g.schema(field.Type)
+		if description := field.Tag.Get("description"); description != "" {
+			property = map[string]any{"allOf": []any{property}, "description": description}
+		}
+		properties[name] = property
+
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			required = append(required, name)
+		}
+	}
+
+	object := map[string]any{"type": "object", "properties": properties}
+	if len(required) != 0 {
+		object["required"] = required
+	}
+	return object
+}
+
+// structFields function    展开匿名内嵌结构体后的导出字段
+func structFields(t reflect.Type) []reflect.StructField {
+	fields := make([]reflect.StructField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			if ft := indirectType(field.Type); ft.Kind() == reflect.Struct {
+				fields = append(fields, structFields(ft)...)
+				continue
+			}
+		}
+		if field.IsExported() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// indirectType function    去掉指针
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+var schemaNameReplacer = regexp.MustCompile(`[^A-Za-z0-9_.]+`)
+
+// schemaName method    components中的名称, 泛型参数中的特殊字符替换为下划线
+//
+// 不同包的同名类型依次尝试加上包名、完整包路径作为前缀, 仍然冲突时追加序号
+func (g *schemaGenerator) schemaName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	candidates := []string{t.Name()}
+	if pkgPath := t.PkgPath(); pkgPath != "" {
+		candidates = append(candidates, path.Base(pkgPath)+"."+t.Name(), pkgPath+"."+t.Name())
+	}
+
+	var name string
+	for _, candidate := range candidates {
+		name = cleanSchemaName(candidate)
+		if _, taken := g.schemas[name]; !taken {
+			g.names[t] = name
+			return name
+		}
+	}
+
+	base := name
+	for idx := 2; ; idx++ {
+		name = base + "_" + strconv.Itoa(idx)
+		if _, taken := g.schemas[name]; !taken {
+			g.names[t] = name
+			return name
+		}
+	}
+}
+
+// cleanSchemaName function    替换components名称中不允许的字符
+func cleanSchemaName(name string) string {
+	return strings.Trim(schemaNameReplacer.ReplaceAllString(name, "_"), "_")
+}
+
+var ginParamPattern = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// ginPathToOpenAPI function    将 /user/:id 转为 /user/{id}
+func ginPathToOpenAPI(path string) string {
+	return ginParamPattern.ReplaceAllString(path, "{$1}")
+}
+
+// joinPath function    拼接路由组前缀与相对路径
+func joinPath(base, path string) string {
+	if path == "" {
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// URL struct    与net/url.URL同名的类型
+type URL struct {
+	Raw string `json:"raw"`
+}
+
+type Page[T any] struct {
+	Items []T `json:"items"`
+}
+
+func TestSchemaName(t *testing.T) {
+	g := &schemaGenerator{
+		schemas: map[string]any{codeApiSchema: codeApiObject()},
+		names:   make(map[reflect.Type]string),
+	}
+
+	tests := []struct {
+		name string
+		typ  reflect.Type
+		want string
+	}{
+		{name: "first type keeps short name", typ: reflect.TypeOf(URL{}), want: "URL"},
+		{name: "same name from other package", typ: reflect.TypeOf(url.URL{}), want: "url.URL"},
+		{name: "stable for known type", typ: reflect.TypeOf(URL{}), want: "URL"},
+		{name: "generic arguments cleaned", typ: reflect.TypeOf(Page[URL]{}), want: "Page_github.com_Anniext_Arkitektur_server.URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref := g.schema(tt.typ)["$ref"]
+			if ref != schemaRefPrefix+tt.want {
+				t.Errorf("$ref = %v, want %s", ref, schemaRefPrefix+tt.want)
+			}
+			if _, ok := g.schemas[tt.want]; !ok {
+				t.Errorf("schema %s not registered", tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterOpenAPIEscapesPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	RegisterOpenAPI(engine.Group("/api"), OpenAPIInfo{
+		Title:    `</title><script>alert(1)</script>`,
+		AssetURL: "https://static.example.com/swagger/",
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api"+ESwaggerUIPath, nil))
+
+	page := w.Body.String()
+	if strings.Contains(page, "<script>alert(1)</script>") {
+		t.Error("title not escaped")
+	}
+	if !strings.Contains(page, `href="https://static.example.com/swagger/swagger-ui.css"`) {
+		t.Error("asset url not applied")
+	}
+	if strings.Contains(page, "unpkg.com") {
+		t.Error("default asset url still used")
+	}
+}
+
+func TestRegisterOpenAPIServesEmbeddedAssets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	RegisterOpenAPI(engine.Group("/api"), OpenAPIInfo{Title: "demo"})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api"+ESwaggerUIPath, nil))
+	page := w.Body.String()
+	if !strings.Contains(page, `href="/api/docs/assets/swagger-ui.css"`) || !strings.Contains(page, `src="/api/docs/assets/swagger-ui-bundle.js"`) {
+		t.Fatalf("page does not use embedded assets:\n%s", page)
+	}
+	if strings.Contains(page, "https://") {
+		t.Error("page loads a remote asset")
+	}
+
+	tests := []struct {
+		file        string
+		status      int
+		contentType string
+	}{
+		{file: "swagger-ui.css", status: http.StatusOK, contentType: "text/css; charset=utf-8"},
+		{file: "swagger-ui-bundle.js", status: http.StatusOK, contentType: "text/javascript; charset=utf-8"},
+		{file: "swagger-ui.js.map", status: http.StatusNotFound},
+		{file: "index.html", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api"+ESwaggerUIAsset+"/"+tt.file, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status == http.StatusOK && (w.Header().Get("Content-Type") != tt.contentType || w.Body.Len() == 0) {
+				t.Errorf("content type = %q, size = %d", w.Header().Get("Content-Type"), w.Body.Len())
+			}
+		})
+	}
+}
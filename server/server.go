@@ -1,8 +1,12 @@
 package server
 
+import "github.com/Anniext/Arkitektur/server/middlewares"
+
 type GinConfig struct {
-	Addr string
-	Port int
+	Addr    string
+	Port    int
+	Cors    *middlewares.CorsConfig // 为空时使用服务配置中的cors, 都没有时使用默认跨域策略
+	OpenAPI *OpenAPIInfo            // 不为空时在/api下提供openapi.json与Swagger UI, 错误码目录总是提供
 }
 type Option func(*GinConfig)
 
@@ -18,6 +22,18 @@ func WithPortOption(port int) Option {
 	}
 }
 
+func WithCorsOption(cors middlewares.CorsConfig) Option {
+	return func(c *GinConfig) {
+		c.Cors = &cors
+	}
+}
+
+func WithOpenAPIOption(info OpenAPIInfo) Option {
+	return func(c *GinConfig) {
+		c.OpenAPI = &info
+	}
+}
+
 func NewGinOption(options ...Option) {
 	defaultGinConfig = &GinConfig{}
 	for _, option := range options {
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+)
+
+const (
+	ELocaleZh = "zh"
+	ELocaleEn = "en"
+)
+
+// FieldError struct    字段级校验错误, 放在CodeApi.Data中返回
+type FieldError struct {
+	Field   string `json:"field"`           // 参数名, 依次取json、form、uri标签
+	Tag     string `json:"tag"`             // 未通过的校验规则
+	Param   string `json:"param,omitempty"` // 规则参数
+	Message string `json:"message"`         // 本地化提示
+}
+
+var (
+	validatorOnce sync.Once
+	translator    *ut.UniversalTranslator
+)
+
+// GetValidator function    获取gin使用的校验器, 首次调用时注册字段名与中英文翻译
+func GetValidator() *validator.Validate {
+	validate, _ := binding.Validator.Engine().(*validator.Validate)
+	validatorOnce.Do(func() {
+		if validate == nil {
+			return
+		}
+
+		validate.RegisterTagNameFunc(fieldName)
+
+		zhLocale, enLocale := zh.New(), en.New()
+		translator = ut.New(zhLocale, zhLocale, enLocale)
+		if trans, ok := translator.GetTranslator(ELocaleZh); ok {
+			_ = zhTranslations.RegisterDefaultTranslations(validate, trans)
+		}
+		if trans, ok := translator.GetTranslator(ELocaleEn); ok {
+			_ = enTranslations.RegisterDefaultTranslations(validate, trans)
+		}
+	})
+	return validate
+}
+
+// fieldName function    校验错误中使用请求里的参数名而不是结构体字段名
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// TranslateValidationErrors function    将校验错误转换为本地化的字段错误
+func TranslateValidationErrors(errs validator.ValidationErrors, locale string) []FieldError {
+	GetValidator()
+
+	var trans ut.Translator
+	if translator != nil {
+		trans, _ = translator.GetTranslator(locale)
+	}
+
+	result := make([]FieldError, 0, len(errs))
+	for _, err := range errs {
+		message := err.Error()
+		if trans != nil {
+			message = err.Translate(trans)
+		}
+		result = append(result, FieldError{
+			Field:   err.Field(),
+			Tag:     err.Tag(),
+			Param:   err.Param(),
+			Message: message,
+		})
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package sse
+
+type SSEConfig struct {
+	ReplaySize int    // 断线续传的补发缓冲条数
+	Heartbeat  int    // 心跳注释的间隔(秒)
+	BufferSize int    // 单个会话的发送缓冲, 写满视为慢连接并断开
+	UidClaim   string // 会话uid使用的token声明
+}
+type Option func(*SSEConfig)
+
+func WithReplaySizeOption(replaySize int) Option {
+	return func(c *SSEConfig) {
+		c.ReplaySize = replaySize
+	}
+}
+
+func WithHeartbeatOption(heartbeat int) Option {
+	return func(c *SSEConfig) {
+		c.Heartbeat = heartbeat
+	}
+}
+
+func WithBufferSizeOption(bufferSize int) Option {
+	return func(c *SSEConfig) {
+		c.BufferSize = bufferSize
+	}
+}
+
+func WithUidClaimOption(uidClaim string) Option {
+	return func(c *SSEConfig) {
+		c.UidClaim = uidClaim
+	}
+}
+
+func NewSSEOption(options ...Option) {
+	defaultSSEConfig = &SSEConfig{}
+	for _, option := range options {
+		option(defaultSSEConfig)
+	}
+}
+
+var defaultSSEConfig *SSEConfig
+
+func GetDefaultSSEConfig() *SSEConfig {
+	return defaultSSEConfig
+}
@@ -0,0 +1,18 @@
+package sse
+
+var defaultHub *Hub
+
+// InitDefaultSSE function    按默认配置新建会话管理器, 通过 GetDefaultGin().GET(path, GetDefaultHub().Handler()) 挂载
+func InitDefaultSSE() error {
+	cnf := GetDefaultSSEConfig()
+	if cnf == nil {
+		cnf = &SSEConfig{}
+	}
+
+	defaultHub = NewHub(*cnf)
+	return nil
+}
+
+func GetDefaultHub() *Hub {
+	return defaultHub
+}
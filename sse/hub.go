@@ -0,0 +1,249 @@
+package sse
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Anniext/Arkitektur/common"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultReplaySize = 256 // 默认补发缓冲条数
+	DefaultHeartbeat  = 15  // 默认心跳间隔(秒)
+	DefaultBufferSize = 64  // 默认会话发送缓冲
+	DefaultUidClaim   = "uid"
+
+	ELastEventIdHeader = "Last-Event-ID" // 浏览器重连时自动携带
+	ELastEventIdQuery  = "lastEventId"   // 无法设置请求头的客户端使用query参数
+)
+
+var _ common.Publisher = (*Hub)(nil)
+
+// Event struct    推送事件, id在会话管理器内单调递增
+type Event struct {
+	Id    uint64
+	Uid   int32  // 为0时广播
+	Event string // 事件名, 按msgNo推送时为msgNo的十进制
+	Data  []byte
+}
+
+// Hub struct    sse会话管理器, 寻址方式与websocket.WsSessionHub一致
+type Hub struct {
+	mu                   sync.RWMutex
+	seq                  uint64                          // 事件id
+	replay               []*Event                        // 补发缓冲, 按id升序
+	sessions             map[*Session]struct{}           // 建立连接的会话
+	uidSessions          map[int32]map[*Session]struct{} // uid对应的会话, 同一uid可以打开多个页面
+	sessionNum           int32                           // 建立连接的数量
+	sessionExitFunctions []func(*Session, int32)         // 会话关闭回调
+	config               SSEConfig
+}
+
+// NewHub function    新建会话管理器, 未配置的项使用默认值
+func NewHub(cnf SSEConfig) *Hub {
+	if cnf.ReplaySize <= 0 {
+		cnf.ReplaySize = DefaultReplaySize
+	}
+	if cnf.Heartbeat <= 0 {
+		cnf.Heartbeat = DefaultHeartbeat
+	}
+	if cnf.BufferSize <= 0 {
+		cnf.BufferSize = DefaultBufferSize
+	}
+	if cnf.UidClaim == "" {
+		cnf.UidClaim = DefaultUidClaim
+	}
+
+	return &Hub{
+		replay:      make([]*Event, 0, cnf.ReplaySize),
+		sessions:    make(map[*Session]struct{}),
+		uidSessions: make(map[int32]map[*Session]struct{}),
+		config:      cnf,
+	}
+}
+
+// Handler method    sse握手处理, 可挂载到gin的任意路由上, 与其他接口共用中间件
+func (h *Hub) Handler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		lastEventId := ctx.GetHeader(ELastEventIdHeader)
+		if lastEventId == "" {
+			lastEventId = ctx.Query(ELastEventIdQuery)
+		}
+		lastId, _ := strconv.ParseUint(lastEventId, 10, 64)
+
+		session := newSession(h, ctx)
+		replay := h.subscribe(session, lastId)
+		defer h.unsubscribe(session)
+
+		header := ctx.Writer.Header()
+		header.Set("Content-Type", "text/event-stream")
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Connection", "keep-alive")
+		header.Set("X-Accel-Buffering", "no")
+		ctx.Status(http.StatusOK)
+
+		ctx.Writer.Flush()
+		for _, event := range replay {
+			if !session.write(ctx, event) {
+				return
+			}
+		}
+
+		session.serve(ctx, time.Duration(h.config.Heartbeat)*time.Second)
+	}
+}
+
+// subscribe method    登记会话并返回需要补发的事件, 与发布互斥保证事件不丢不重
+func (h *Hub) subscribe(session *Session, lastId uint64) []*Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.sessions[session] = struct{}{}
+	if session.uid != 0 {
+		if h.uidSessions[session.uid] == nil {
+			h.uidSessions[session.uid] = make(map[*Session]struct{})
+		}
+		h.uidSessions[session.uid][session] = struct{}{}
+	}
+	atomic.AddInt32(&h.sessionNum, 1)
+
+	if lastId == 0 {
+		return nil
+	}
+
+	replay := make([]*Event, 0)
+	for _, event := range h.replay {
+		if event.Id > lastId && (event.Uid == 0 || event.Uid == session.uid) {
+			replay = append(replay, event)
+		}
+	}
+	return replay
+}
+
+// unsubscribe method    注销会话, 执行剩余工作与关闭回调
+func (h *Hub) unsubscribe(session *Session) {
+	session.Close()
+
+	h.mu.Lock()
+	delete(h.sessions, session)
+	if sessions, ok := h.uidSessions[session.uid]; ok {
+		delete(sessions, session)
+		if len(sessions) == 0 {
+			delete(h.uidSessions, session.uid)
+		}
+	}
+	atomic.AddInt32(&h.sessionNum, -1)
+	exitFunctions := h.sessionExitFunctions
+	h.mu.Unlock()
+
+	session.runWork()
+	for _, fn := range exitFunctions {
+		fn(session, session.uid)
+	}
+}
+
+// publish method    生成事件并投递, 对方不在线时只写入补发缓冲
+func (h *Hub) publish(uid int32, name string, data []byte) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	event := &Event{Id: h.seq, Uid: uid, Event: name, Data: data}
+	if len(h.replay) >= h.config.ReplaySize {
+		copy(h.replay, h.replay[1:])
+		h.replay = h.replay[:len(h.replay)-1]
+	}
+	h.replay = append(h.replay, event)
+
+	targets := h.sessions
+	if uid != 0 {
+		targets = h.uidSessions[uid]
+	}
+
+	delivered := false
+	for session := range targets {
+		if session.deliver(event) {
+			delivered = true
+		}
+	}
+	return delivered
+}
+
+// Push method    按msgNo推送给uid, 事件名为msgNo
+func (h *Hub) Push(uid int32, msgNo uint32, body []byte) bool {
+	if uid == 0 {
+		return false
+	}
+	return h.publish(uid, strconv.FormatUint(uint64(msgNo), 10), body)
+}
+
+// PushAll method    按msgNo推送给全部连接
+func (h *Hub) PushAll(msgNo uint32, body []byte) {
+	h.publish(0, strconv.FormatUint(uint64(msgNo), 10), body)
+}
+
+// PushEvent method    按事件名推送给uid
+func (h *Hub) PushEvent(uid int32, event string, data []byte) bool {
+	if uid == 0 {
+		return false
+	}
+	return h.publish(uid, event, data)
+}
+
+// PushEventAll method    按事件名推送给全部连接
+func (h *Hub) PushEventAll(event string, data []byte) {
+	h.publish(0, event, data)
+}
+
+// PushWork method    提交到uid全部会话的工作队列
+func (h *Hub) PushWork(uid int32, fn func()) bool {
+	pushed := false
+	for _, session := range h.Get(uid) {
+		if session.PushWork(fn) {
+			pushed = true
+		}
+	}
+	return pushed
+}
+
+// Get method    获取uid的会话
+func (h *Hub) Get(uid int32) []*Session {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	sessions := make([]*Session, 0, len(h.uidSessions[uid]))
+	for session := range h.uidSessions[uid] {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// SessionNum method    获取会话数量
+func (h *Hub) SessionNum() int32 {
+	return atomic.LoadInt32(&h.sessionNum)
+}
+
+// AtSessionClose method    增加会话关闭回调函数
+func (h *Hub) AtSessionClose(fn func(*Session, int32)) {
+	h.mu.Lock()
+	h.sessionExitFunctions = append(h.sessionExitFunctions, fn)
+	h.mu.Unlock()
+}
+
+// Exit method    关闭全部会话
+func (h *Hub) Exit() {
+	h.mu.RLock()
+	sessions := make([]*Session, 0, len(h.sessions))
+	for session := range h.sessions {
+		sessions = append(sessions, session)
+	}
+	h.mu.RUnlock()
+
+	for _, session := range sessions {
+		session.Close()
+	}
+}
@@ -0,0 +1,72 @@
+package sse
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readEvent function    读取一条事件的全部行
+func readEvent(t *testing.T, reader *bufio.Reader) []string {
+	t.Helper()
+
+	lines := make([]string, 0)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(lines) == 0 {
+				continue
+			}
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestHubReplayAndSanitize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	hub := NewHub(SSEConfig{})
+	engine := gin.New()
+	engine.GET("/sse", hub.Handler())
+	ts := httptest.NewServer(engine)
+	defer ts.Close()
+	defer hub.Exit()
+
+	hub.PushEventAll("first", []byte(`{"n":1}`))
+	hub.PushEventAll("second\r\nevent: forged", []byte(`plain text`))
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/sse", nil)
+	req.Header.Set(ELastEventIdHeader, "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	lines := readEvent(t, bufio.NewReader(resp.Body))
+	if len(lines) != 3 {
+		t.Fatalf("event lines = %q, want id, event, data", lines)
+	}
+	if lines[0] != "id:2" {
+		t.Errorf("id line = %q, want id:2", lines[0])
+	}
+	if lines[1] != "event:secondevent: forged" {
+		t.Errorf("event line = %q, want CR/LF stripped", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "data:{") || !strings.Contains(lines[2], `"data":"plain text"`) {
+		t.Errorf("data line = %q, want CodeApi envelope with string data", lines[2])
+	}
+}
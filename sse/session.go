@@ -0,0 +1,159 @@
+package sse
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Anniext/Arkitektur/common"
+	"github.com/Anniext/Arkitektur/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Session struct    sse会话
+type Session struct {
+	uid       int32
+	hub       *Hub
+	events    chan *Event // 发送缓冲
+	workMu    sync.Mutex
+	works     []func()      // 工作队列
+	notify    chan struct{} // 有新的工作
+	done      chan struct{}
+	closeOnce sync.Once
+	Request   *http.Request // http请求句柄
+	Context   sync.Map
+}
+
+// newSession function    新建会话, uid取自token声明
+func newSession(hub *Hub, ctx *gin.Context) *Session {
+	s := &Session{
+		hub:     hub,
+		events:  make(chan *Event, hub.config.BufferSize),
+		notify:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		Request: ctx.Request,
+	}
+
+	if claims, ok := ctx.Value("claims").(map[string]any); ok {
+		s.uid = int32(utils.GetMapSpecificValue[int64](claims, hub.config.UidClaim))
+	}
+	return s
+}
+
+// GetUid method    获取uid, 未登录为0
+func (s *Session) GetUid() int32 {
+	return s.uid
+}
+
+// Close method    关闭会话
+func (s *Session) Close() bool {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	return true
+}
+
+// Dead method    会话是否结束
+func (s *Session) Dead() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// PushWork method    将任务函数提交到工作队列, 在会话协程中执行
+func (s *Session) PushWork(fn func()) bool {
+	if s.Dead() {
+		return false
+	}
+
+	s.workMu.Lock()
+	s.works = append(s.works, utils.SafeGoRecoverWarpFunc(fn))
+	s.workMu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// deliver method    投递事件, 缓冲写满时断开慢连接, 客户端重连后按Last-Event-ID补发
+func (s *Session) deliver(event *Event) bool {
+	if s.Dead() {
+		return false
+	}
+
+	select {
+	case s.events <- event:
+		return true
+	default:
+		log.Println("sse session buffer full, close", s.uid, s.Request.RemoteAddr)
+		s.Close()
+		return false
+	}
+}
+
+// serve method    循环写出事件与心跳, 连接断开或会话关闭时返回
+func (s *Session) serve(ctx *gin.Context, heartbeat time.Duration) {
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		var err error
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-s.done:
+			return
+		case event := <-s.events:
+			if !s.write(ctx, event) {
+				return
+			}
+			continue
+		case <-s.notify:
+			s.runWork()
+			continue
+		case <-ticker.C:
+			_, err = io.WriteString(ctx.Writer, ": heartbeat\n\n")
+		}
+
+		if err != nil {
+			return
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+// runWork method    执行工作队列中的任务
+func (s *Session) runWork() {
+	s.workMu.Lock()
+	works := s.works
+	s.works = nil
+	s.workMu.Unlock()
+
+	for _, work := range works {
+		work()
+	}
+}
+
+// write method    通过common.WriteSSEvent写出一条事件, 合法json原样嵌入信封, 否则按字符串处理, 返回false表示连接已断开
+func (s *Session) write(ctx *gin.Context, event *Event) bool {
+	var payload any = string(event.Data)
+	if json.Valid(event.Data) {
+		payload = json.RawMessage(event.Data)
+	}
+
+	common.WriteSSEvent(ctx, common.SSEvent{
+		Id:    strconv.FormatUint(event.Id, 10),
+		Event: event.Event,
+		Data:  payload,
+	})
+	return !ctx.IsAborted()
+}
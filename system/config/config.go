@@ -14,6 +14,29 @@ type ServerConfig struct {
 	TweetInfo     TweetInfo     `mapstructure:"tweet" json:"tweet" yaml:"tweet"`
 	BinanceInfo   BinanceInfo   `mapstructure:"binance" json:"binance" yaml:"binance"`
 	BarkInfo      BarkInfo      `mapstructure:"bark" json:"bark" yaml:"bark"`
+	CorsInfo      CorsInfo      `mapstructure:"cors" json:"cors" yaml:"cors"`
+	SSEInfo       SSEInfo       `mapstructure:"sse" json:"sse" yaml:"sse"`
+}
+
+type CorsInfo struct {
+	AllowOrigins     []string        `mapstructure:"allow_origins" json:"allow_origins" yaml:"allow_origins"` // 精确或 "*.example.com" 通配子域名
+	AllowMethods     []string        `mapstructure:"allow_methods" json:"allow_methods" yaml:"allow_methods"`
+	AllowHeaders     []string        `mapstructure:"allow_headers" json:"allow_headers" yaml:"allow_headers"`
+	ExposeHeaders    []string        `mapstructure:"expose_headers" json:"expose_headers" yaml:"expose_headers"`
+	AllowCredentials bool            `mapstructure:"allow_credentials" json:"allow_credentials" yaml:"allow_credentials"`
+	MaxAge           int             `mapstructure:"max_age" json:"max_age" yaml:"max_age"` // 预检缓存秒数
+	Routes           []CorsRouteInfo `mapstructure:"routes" json:"routes" yaml:"routes"`    // 按路由覆盖
+}
+
+// CorsRouteInfo 按路由覆盖的跨域配置, 未配置的字段继承全局配置
+type CorsRouteInfo struct {
+	Path             string   `mapstructure:"path" json:"path" yaml:"path"`
+	AllowOrigins     []string `mapstructure:"allow_origins" json:"allow_origins" yaml:"allow_origins"`
+	AllowMethods     []string `mapstructure:"allow_methods" json:"allow_methods" yaml:"allow_methods"`
+	AllowHeaders     []string `mapstructure:"allow_headers" json:"allow_headers" yaml:"allow_headers"`
+	ExposeHeaders    []string `mapstructure:"expose_headers" json:"expose_headers" yaml:"expose_headers"`
+	AllowCredentials *bool    `mapstructure:"allow_credentials" json:"allow_credentials" yaml:"allow_credentials"`
+	MaxAge           int      `mapstructure:"max_age" json:"max_age" yaml:"max_age"`
 }
 
 type TweetInfo struct {
@@ -64,9 +87,18 @@ type MinioConfig struct {
 	Token           string `mapstructure:"token" json:"token" yaml:"token"`
 }
 
+// CasbinInfo casbin配置文件 可选
 type CasbinInfo struct {
-	Enable    bool   `mapstructure:"enable" json:"enable" yaml:"enable"`
-	ModelPath string `mapstructure:"modelPath" json:"modelPath" yaml:"modelPath"`
+	Enable         bool     `mapstructure:"enable" json:"enable" yaml:"enable"`
+	ModelPath      string   `mapstructure:"modelPath" json:"modelPath" yaml:"modelPath"`
+	Domain         bool     `mapstructure:"domain" json:"domain" yaml:"domain"`                         // 多租户RBAC模型
+	RolesClaim     string   `mapstructure:"rolesClaim" json:"rolesClaim" yaml:"rolesClaim"`             // 中间件使用的角色列表声明
+	PublicRoutes   []string `mapstructure:"publicRoutes" json:"publicRoutes" yaml:"publicRoutes"`       // 中间件免鉴权路由
+	Adapter        string   `mapstructure:"adapter" json:"adapter" yaml:"adapter"`                      // xorm、file、redis、memory
+	PolicyPath     string   `mapstructure:"policyPath" json:"policyPath" yaml:"policyPath"`             // file适配器策略文件
+	RedisKey       string   `mapstructure:"redisKey" json:"redisKey" yaml:"redisKey"`                   // redis适配器存储key
+	Watcher        string   `mapstructure:"watcher" json:"watcher" yaml:"watcher"`                      // 策略同步: redis、mqtt
+	WatcherChannel string   `mapstructure:"watcherChannel" json:"watcherChannel" yaml:"watcherChannel"` // 策略同步频道
 }
 
 type MqttConfig struct {
@@ -81,10 +113,34 @@ type MqttConfig struct {
 }
 
 type WebsocketInfo struct {
-	Enable      bool `mapstructure:"enable" json:"enable" yaml:"enable"`
-	Port        int  `mapstructure:"port" json:"port" yaml:"port"`
-	TimeoutRead int  `mapstructure:"timeout_read" json:"timeout_read" yaml:"timeout_read"`
+	Enable       bool     `mapstructure:"enable" json:"enable" yaml:"enable"`
+	Port         int      `mapstructure:"port" json:"port" yaml:"port"`
+	Path         string   `mapstructure:"path" json:"path" yaml:"path"` // 挂载到gin的路由, 不为空时不单独监听端口
+	TimeoutRead  int      `mapstructure:"timeout_read" json:"timeout_read" yaml:"timeout_read"`
+	AllowOrigins []string `mapstructure:"allow_origins" json:"allow_origins" yaml:"allow_origins"` // origin白名单
+	AuthEnable   bool     `mapstructure:"auth_enable" json:"auth_enable" yaml:"auth_enable"`       // 握手鉴权
+	AuthRequired bool     `mapstructure:"auth_required" json:"auth_required" yaml:"auth_required"` // 必须携带token
+	Cluster      string   `mapstructure:"cluster" json:"cluster" yaml:"cluster"`                   // 集群总线: redis、mqtt
+	Node         string   `mapstructure:"node" json:"node" yaml:"node"`                            // 集群节点id
+	PingInterval int      `mapstructure:"ping_interval" json:"ping_interval" yaml:"ping_interval"` // ping间隔秒数
+	PingMissed   int      `mapstructure:"ping_missed" json:"ping_missed" yaml:"ping_missed"`       // 未响应ping次数上限
+	Heartbeat    uint32   `mapstructure:"heartbeat" json:"heartbeat" yaml:"heartbeat"`             // 应用层心跳协议号
+	QueueLen     int      `mapstructure:"queue_len" json:"queue_len" yaml:"queue_len"`             // 写队列消息数上限
+	QueueBytes   int      `mapstructure:"queue_bytes" json:"queue_bytes" yaml:"queue_bytes"`       // 写队列字节数上限
+	QueuePolicy  string   `mapstructure:"queue_policy" json:"queue_policy" yaml:"queue_policy"`    // drop_oldest、drop_newest、disconnect
+	TimeoutWrite int      `mapstructure:"timeout_write" json:"timeout_write" yaml:"timeout_write"` // 写超时秒数
+	Workers      int      `mapstructure:"workers" json:"workers" yaml:"workers"`                   // 协议回调协程池大小
+	TimeoutProto int      `mapstructure:"timeout_proto" json:"timeout_proto" yaml:"timeout_proto"` // 协议回调超时秒数
+	ReadLimit    int64    `mapstructure:"read_limit" json:"read_limit" yaml:"read_limit"`          // 单帧读取上限(字节)
+}
+type SSEInfo struct {
+	Enable     bool   `mapstructure:"enable" json:"enable" yaml:"enable"`
+	ReplaySize int    `mapstructure:"replay_size" json:"replay_size" yaml:"replay_size"` // 断线续传补发条数
+	Heartbeat  int    `mapstructure:"heartbeat" json:"heartbeat" yaml:"heartbeat"`       // 心跳间隔(秒)
+	BufferSize int    `mapstructure:"buffer_size" json:"buffer_size" yaml:"buffer_size"` // 会话发送缓冲
+	UidClaim   string `mapstructure:"uid_claim" json:"uid_claim" yaml:"uid_claim"`       // uid声明
 }
+
 type BinanceInfo struct {
 	Proxy     string `mapstructure:"proxy" json:"proxy" yaml:"proxy"`
 	ApiKey    string `mapstructure:"apiKey" json:"apiKey" yaml:"apiKey"`
@@ -72,3 +72,11 @@ func GetEncoder() zapcore.Encoder {
 func customTimeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
 	enc.AppendString(t.Format("2006-01-02 15:04:05.000"))
 }
+
+// GetLogger function    获取zap日志, 未初始化时返回zap全局日志
+func GetLogger() *zap.Logger {
+	if log == nil {
+		return zap.L()
+	}
+	return log
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -70,6 +71,42 @@ func GetMapSpecificValue[T MapSupportedTypes](m map[string]any, key string) T {
 	return zero
 }
 
+// GetMapStrings 获取map中的字符串列表, 兼容单个值与数组, 数字转为字符串
+func GetMapStrings(m map[string]any, key string) []string {
+	value, exists := m[key]
+	if !exists || value == nil {
+		return nil
+	}
+
+	var items []any
+	switch v := value.(type) {
+	case []any:
+		items = v
+	case []string:
+		return v
+	default:
+		items = []any{v}
+	}
+
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			if v != "" {
+				result = append(result, v)
+			}
+		case float64:
+			result = append(result, strconv.FormatFloat(v, 'f', -1, 64))
+		case int64:
+			result = append(result, strconv.FormatInt(v, 10))
+		case int:
+			result = append(result, strconv.Itoa(v))
+		}
+	}
+
+	return result
+}
+
 // GetMapValue 获取map的值
 func GetMapValue[T any](m map[string]interface{}, key string) T {
 	var zero T
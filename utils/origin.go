@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// MatchOrigin 判断origin是否在白名单中, 支持 "*"、精确匹配与 "*.example.com" 通配子域名
+func MatchOrigin(origin string, allowOrigins []string) bool {
+	if origin == "" {
+		return false
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+
+	for _, allow := range allowOrigins {
+		allow = strings.ToLower(strings.TrimSpace(allow))
+		switch {
+		case allow == "*":
+			return true
+		case allow == strings.ToLower(origin):
+			return true
+		case strings.HasPrefix(allow, "*."):
+			if strings.HasSuffix(host, allow[1:]) {
+				return true
+			}
+		case strings.Contains(allow, "://*."):
+			scheme, domain, _ := strings.Cut(allow, "://*")
+			if scheme == strings.ToLower(u.Scheme) && strings.HasSuffix(host, domain) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,35 @@
+package utils
+
+import "testing"
+
+func TestMatchOrigin(t *testing.T) {
+	tests := []struct {
+		name   string
+		origin string
+		allow  []string
+		want   bool
+	}{
+		{name: "empty origin", origin: "", allow: []string{"*"}, want: false},
+		{name: "invalid origin", origin: "not a url", allow: []string{"*"}, want: false},
+		{name: "wildcard", origin: "https://a.com", allow: []string{"*"}, want: true},
+		{name: "exact", origin: "https://a.com", allow: []string{"https://a.com"}, want: true},
+		{name: "exact case insensitive", origin: "https://A.com", allow: []string{" https://a.COM "}, want: true},
+		{name: "exact scheme mismatch", origin: "http://a.com", allow: []string{"https://a.com"}, want: false},
+		{name: "exact port mismatch", origin: "https://a.com:8443", allow: []string{"https://a.com"}, want: false},
+		{name: "subdomain", origin: "https://api.example.com", allow: []string{"*.example.com"}, want: true},
+		{name: "subdomain any scheme", origin: "http://api.example.com:8080", allow: []string{"*.example.com"}, want: true},
+		{name: "subdomain excludes apex", origin: "https://example.com", allow: []string{"*.example.com"}, want: false},
+		{name: "subdomain suffix attack", origin: "https://evilexample.com", allow: []string{"*.example.com"}, want: false},
+		{name: "scheme subdomain", origin: "https://api.example.com", allow: []string{"https://*.example.com"}, want: true},
+		{name: "scheme subdomain mismatch", origin: "http://api.example.com", allow: []string{"https://*.example.com"}, want: false},
+		{name: "no match", origin: "https://b.com", allow: []string{"https://a.com", "*.a.com"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchOrigin(tt.origin, tt.allow); got != tt.want {
+				t.Errorf("MatchOrigin(%q, %v) = %v, want %v", tt.origin, tt.allow, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Anniext/Arkitektur/casbin"
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/Anniext/Arkitektur/jwt"
+	"github.com/Anniext/Arkitektur/utils"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	EAuthQueryKey          = "token"         // 默认query参数名
+	EAuthHeaderKey         = "Authorization" // 默认请求头名
+	EAuthSubprotocolPrefix = "bearer."       // 默认子协议前缀
+	EAuthUidClaim          = "uid"           // 默认uid声明名
+	EAuthRoleClaim         = "role_id"       // 默认角色声明名
+	ECasbinAction          = "WS"            // casbin中websocket协议的动作
+	EGinClaimsKey          = "claims"        // gin中间件写入声明的key, 与jwt.GetTokenData保持一致
+)
+
+// claimsKey 请求上下文中保存握手声明的key
+type claimsKey struct{}
+
+// withClaims function    将声明写入请求上下文
+func withClaims(req *http.Request, claims map[string]any) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), claimsKey{}, claims))
+}
+
+// claimsFrom function    从请求上下文中读取声明
+func claimsFrom(req *http.Request) map[string]any {
+	claims, _ := req.Context().Value(claimsKey{}).(map[string]any)
+	return claims
+}
+
+// WsAuth struct    握手鉴权配置
+type WsAuth struct {
+	Required          bool   // 为true时没有token直接拒绝握手
+	QueryKey          string // query参数名
+	HeaderKey         string // 请求头名, 支持 "Bearer " 前缀
+	SubprotocolPrefix string // Sec-WebSocket-Protocol中携带token的前缀
+	UidClaim          string // 自动SetUid使用的声明, 为空不设置
+}
+
+// NewWsAuth function    新建默认握手鉴权配置
+func NewWsAuth(required bool) *WsAuth {
+	return &WsAuth{
+		Required:          required,
+		QueryKey:          EAuthQueryKey,
+		HeaderKey:         EAuthHeaderKey,
+		SubprotocolPrefix: EAuthSubprotocolPrefix,
+		UidClaim:          EAuthUidClaim,
+	}
+}
+
+// extractToken method    依次从query、请求头、子协议中获取token, 返回携带token的子协议
+func (a *WsAuth) extractToken(req *http.Request) (token string, protocol string) {
+	if a.QueryKey != "" {
+		if token = req.URL.Query().Get(a.QueryKey); token != "" {
+			return
+		}
+	}
+
+	if a.HeaderKey != "" {
+		if token = req.Header.Get(a.HeaderKey); token != "" {
+			if len(token) > 7 && strings.EqualFold(token[:7], "Bearer ") {
+				token = token[7:]
+			}
+			return
+		}
+	}
+
+	if a.SubprotocolPrefix != "" {
+		for _, p := range websocket.Subprotocols(req) {
+			if strings.HasPrefix(p, a.SubprotocolPrefix) {
+				return strings.TrimPrefix(p, a.SubprotocolPrefix), p
+			}
+		}
+	}
+
+	return "", ""
+}
+
+// verify method    校验token, 成功时返回声明
+func (a *WsAuth) verify(req *http.Request) (claims map[string]any, protocol string, errCode code.ErrCode) {
+	token, protocol := a.extractToken(req)
+	if token == "" {
+		if a.Required {
+			return nil, "", code.ErrCodeJwtNotEvenAToken
+		}
+		return nil, "", code.NoErrCode
+	}
+
+	claims, errCode = jwt.NewJwt().ParseJwtToken(token)
+	return claims, protocol, errCode
+}
+
+// SetAuth method    设置握手鉴权, nil表示关闭鉴权
+func (s *WsServer) SetAuth(auth *WsAuth) {
+	s.auth = auth
+}
+
+// SetAllowOrigins method    设置允许握手的origin白名单, 为空时不校验
+func (s *WsServer) SetAllowOrigins(origins ...string) {
+	s.allowOrigins = origins
+}
+
+// checkOrigin method    校验握手origin
+func (s *WsServer) checkOrigin(req *http.Request) bool {
+	if len(s.allowOrigins) == 0 {
+		return true
+	}
+
+	return utils.MatchOrigin(req.Header.Get("Origin"), s.allowOrigins)
+}
+
+// authenticate method    握手前鉴权, 声明写入请求上下文
+func (s *WsServer) authenticate(resp http.ResponseWriter, req *http.Request) (*http.Request, http.Header, bool) {
+	if s.auth == nil {
+		return req, nil, true
+	}
+
+	var claims map[string]any
+	var protocol string
+	if claimsFrom(req) != nil {
+		// 挂载到gin时由中间件完成鉴权, 只需回显子协议
+		_, protocol = s.auth.extractToken(req)
+	} else {
+		var errCode code.ErrCode
+		claims, protocol, errCode = s.auth.verify(req)
+		if errCode != code.NoErrCode {
+			log.Println("websocket auth fail", req.RemoteAddr, errCode.String())
+			http.Error(resp, errCode.String(), http.StatusUnauthorized)
+			return req, nil, false
+		}
+	}
+
+	var header http.Header
+	if protocol != "" && len(s.handler.upgrade.Subprotocols) == 0 {
+		// 浏览器要求服务端回显一个客户端提供的子协议
+		header = http.Header{"Sec-Websocket-Protocol": []string{protocol}}
+	}
+
+	if claims != nil {
+		req = withClaims(req, claims)
+	}
+
+	return req, header, true
+}
+
+// bindUid method    根据声明自动设置uid, uid超出int32范围时返回ErrCodeJwtClaimsInvalid, uid已在线时返回EErrCodeRepeatedLogin
+func (s *WsServer) bindUid(ws *WsSession) code.ErrCode {
+	if s.auth == nil || s.auth.UidClaim == "" {
+		return code.NoErrCode
+	}
+
+	claims := ws.GetClaims()
+	if claims == nil {
+		return code.NoErrCode
+	}
+
+	uid := utils.GetMapSpecificValue[int64](claims, s.auth.UidClaim)
+	if uid == 0 {
+		return code.NoErrCode
+	}
+
+	// 截断后会绑定到其他用户的uid
+	if uid < math.MinInt32 || uid > math.MaxInt32 {
+		return code.ErrCodeJwtClaimsInvalid
+	}
+
+	if !ws.SetUid(int32(uid)) {
+		return code.EErrCodeRepeatedLogin
+	}
+	return code.NoErrCode
+}
+
+// GetClaims method    获取握手时解析的token声明
+func (s *WsSession) GetClaims() map[string]any {
+	if s.Request == nil {
+		return nil
+	}
+
+	return claimsFrom(s.Request)
+}
+
+// AuthMiddleware function    要求会话握手时携带了有效token, token的exp过期后拒绝后续协议
+func AuthMiddleware() Middleware {
+	return func(protoFunc ProtoFunc) ProtoFunc {
+		return func(session *WsSession, message IMessage) []byte {
+			claims := session.GetClaims()
+			if claims == nil {
+				log.Println("auth deny without token, msgNo", session.RemoteAddr(), message.GetMsgNo())
+				return session.ErrorReply(code.ErrCodeJwtNotEvenAToken)
+			}
+
+			exp := utils.GetMapSpecificValue[int64](claims, "exp")
+			if exp != 0 && time.Now().Unix() >= exp {
+				log.Println("auth deny with expired token, msgNo", session.RemoteAddr(), message.GetMsgNo())
+				return session.ErrorReply(code.ErrCodeJwtTokenIsExpired)
+			}
+
+			return protoFunc(session, message)
+		}
+	}
+}
+
+// CasbinMiddleware function    按msgNo校验会话角色的casbin权限, 对象为msgNo, 动作为ECasbinAction
+func CasbinMiddleware(roleClaim string) Middleware {
+	if roleClaim == "" {
+		roleClaim = EAuthRoleClaim
+	}
+
+	return func(protoFunc ProtoFunc) ProtoFunc {
+		return func(session *WsSession, message IMessage) []byte {
+			enforcer := casbin.GetDefaultCasbin()
+			if enforcer == nil {
+				log.Println("casbin not active, msgNo", message.GetMsgNo())
+				return session.ErrorReply(code.ErrCodeCasbinNotActiveYet)
+			}
+
+			roleId := utils.GetMapSpecificValue[int64](session.GetClaims(), roleClaim)
+			if roleId == 0 {
+				log.Println("casbin deny without role, msgNo", session.ClientIP(), message.GetMsgNo())
+				return session.ErrorReply(code.ErrCodeCasbinNotPermissions)
+			}
+
+			obj := strconv.FormatUint(uint64(message.GetMsgNo()), 10)
+			ok, err := enforcer.Enforce(utils.Int64ToString(roleId), obj, ECasbinAction)
+			if err != nil || !ok {
+				log.Println("casbin deny, msgNo", session.ClientIP(), message.GetMsgNo(), err)
+				return session.ErrorReply(code.ErrCodeCasbinNotPermissions)
+			}
+
+			return protoFunc(session, message)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package websocket
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"sync"
+)
+
+const EBatchFlushBytes = 64 * 1024 // 批量写出时缓冲超过该大小先写出一次
+
+// batchConn struct    升级后的底层连接, 批量写出期间把gorilla逐帧的写入合并成一次写出
+type batchConn struct {
+	net.Conn
+	mu       sync.Mutex
+	buf      []byte
+	batching bool
+}
+
+// Write method    批量写出期间写入缓冲, 否则直接写到连接
+func (c *batchConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.batching {
+		return c.Conn.Write(p)
+	}
+	if len(c.buf)+len(p) > EBatchFlushBytes {
+		if err := c.flushLocked(); err != nil {
+			return 0, err
+		}
+		if len(p) >= EBatchFlushBytes {
+			return c.Conn.Write(p)
+		}
+	}
+	c.buf = append(c.buf, p...)
+	return len(p), nil
+}
+
+// begin method    开始批量写出
+func (c *batchConn) begin() {
+	c.mu.Lock()
+	c.batching = true
+	c.mu.Unlock()
+}
+
+// flush method    结束批量写出并把缓冲一次写到连接
+func (c *batchConn) flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.batching = false
+	return c.flushLocked()
+}
+
+func (c *batchConn) flushLocked() error {
+	if len(c.buf) == 0 {
+		return nil
+	}
+	_, err := c.Conn.Write(c.buf)
+	c.buf = c.buf[:0]
+	return err
+}
+
+// batchResponseWriter struct    升级时把劫持的连接包装成batchConn
+type batchResponseWriter struct {
+	http.ResponseWriter
+}
+
+// Hijack method    劫持连接, 握手响应在批量写出开始前直接写出
+func (w batchResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &batchConn{Conn: conn}, brw, nil
+}
+
+// Unwrap method    供http.ResponseController访问原始的ResponseWriter
+func (w batchResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,144 @@
+package websocket
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// countConn struct    记录写到连接的每次写入
+type countConn struct {
+	net.Conn
+	mu     sync.Mutex
+	writes [][]byte
+}
+
+func (c *countConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	c.writes = append(c.writes, append([]byte(nil), p...))
+	c.mu.Unlock()
+	if c.Conn == nil {
+		return len(p), nil
+	}
+	return c.Conn.Write(p)
+}
+
+func (c *countConn) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.writes)
+}
+
+func TestBatchConn(t *testing.T) {
+	big := bytes.Repeat([]byte{'x'}, EBatchFlushBytes)
+
+	tests := []struct {
+		name   string
+		batch  bool
+		writes [][]byte
+		want   []int // 每次写到连接的字节数
+	}{
+		{name: "pass through", writes: [][]byte{[]byte("ab"), []byte("cd")}, want: []int{2, 2}},
+		{name: "coalesce", batch: true, writes: [][]byte{[]byte("ab"), []byte("cd"), []byte("e")}, want: []int{5}},
+		{name: "flush when full", batch: true, writes: [][]byte{[]byte("ab"), big[:EBatchFlushBytes-1]}, want: []int{2, EBatchFlushBytes - 1}},
+		{name: "oversize write goes direct", batch: true, writes: [][]byte{[]byte("ab"), big, []byte("c")}, want: []int{2, EBatchFlushBytes, 1}},
+		{name: "empty batch", batch: true, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := &countConn{}
+			conn := &batchConn{Conn: counter}
+
+			if tt.batch {
+				conn.begin()
+			}
+			var want []byte
+			for _, p := range tt.writes {
+				if n, err := conn.Write(p); err != nil || n != len(p) {
+					t.Fatalf("Write = %d, %v, want %d", n, err, len(p))
+				}
+				want = append(want, p...)
+			}
+			if tt.batch {
+				if err := conn.flush(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var got []int
+			var data []byte
+			for _, p := range counter.writes {
+				got = append(got, len(p))
+				data = append(data, p...)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("writes = %v, want %v", got, tt.want)
+			}
+			if !bytes.Equal(data, want) {
+				t.Error("written bytes differ from input")
+			}
+		})
+	}
+}
+
+func TestSessionBatchesQueuedFrames(t *testing.T) {
+	server := NewWsServer("")
+	upgrader := websocket.Upgrader{}
+	conns := make(chan *websocket.Conn, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(batchResponseWriter{w}, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(ts.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	conn := <-conns
+	session := newWsSession(&server.WsSessionHub, conn, nil)
+	if session.batch == nil {
+		t.Fatal("upgraded connection is not batched")
+	}
+	counter := &countConn{Conn: session.batch.Conn}
+	session.batch.Conn = counter
+
+	// 启动前入队, 写协程一次取出全部消息
+	const num = 50
+	for i := 0; i < num; i++ {
+		if !session.PushMsg(&Message{MsgNo: uint32(i + 1), Body: []byte("batch")}) {
+			t.Fatalf("push #%d fail", i)
+		}
+	}
+	session.start()
+
+	for i := 0; i < num; i++ {
+		if msg := readMessage(t, client, BinaryCodec{}); msg.GetMsgNo() != uint32(i+1) {
+			t.Fatalf("message #%d msgNo = %d, want %d", i, msg.GetMsgNo(), i+1)
+		}
+	}
+	if n := counter.count(); n != 1 {
+		t.Errorf("%d frames written in %d writes, want 1", num, n)
+	}
+}
+
+func TestHandlerUpgradesToBatchConn(t *testing.T) {
+	server := startTestServer(t, NewWsServer(""))
+	if _, session := server.dial(t, 1); session.batch == nil {
+		t.Error("session from WsHandler is not batched")
+	}
+}
@@ -39,6 +39,14 @@ type WsClient struct {
 	clientIdPoolMutex    sync.Mutex                                        // clientId 池子 变化锁
 	connectDoneFunctions []func(int32)                                     // 链接完成后回调
 	clientReconnectState []int32                                           // 客户端重连状态 避免并发
+	seq                  uint32                                            // 请求序列号
+	seqDisabled          bool                                              // 不使用序列号关联回包, 对接不回传序列号的旧服务端
+	pushCallback         func(session *WsSession, message IMessage)        // 服务端主动推送回调
+}
+
+// seqKey function    序列号在msgReceive中的key, 最高位置1与getSid的结果区分
+func seqKey(seq uint32) uint64 {
+	return 1<<63 | uint64(seq)
 }
 
 func NewWsClient(maxClientNo int32, getSid func(session *WsSession, message IMessage) uint64) *WsClient {
@@ -98,6 +106,25 @@ func (c *WsClient) SetTimeoutWait(timeout time.Duration) {
 	c.timeoutWait = timeout
 }
 
+// SetSeqEnabled method    是否使用序列号关联回包, 默认开启, 消息需要实现ISeqMessage
+func (c *WsClient) SetSeqEnabled(enabled bool) {
+	c.seqDisabled = !enabled
+}
+
+// AtPush method    注册服务端主动推送的回调, 带推送标记的消息不会被当作回包
+func (c *WsClient) AtPush(fn func(session *WsSession, message IMessage)) {
+	c.pushCallback = fn
+}
+
+// nextSeq method    下一个序列号, 跳过0
+func (c *WsClient) nextSeq() uint32 {
+	for {
+		if seq := atomic.AddUint32(&c.seq, 1); seq != 0 {
+			return seq
+		}
+	}
+}
+
 func (c *WsClient) UnregisteredCallback(session *WsSession, message IMessage) []byte {
 	defer func() {
 		if e := recover(); e != nil {
@@ -106,7 +133,20 @@ func (c *WsClient) UnregisteredCallback(session *WsSession, message IMessage) []
 		} else {
 		}
 	}()
-	sid := c.getSidCallback(session, message)
+	seq, push := seqOf(message)
+	if push {
+		if c.pushCallback != nil {
+			c.pushCallback(session, message)
+		}
+		return nil
+	}
+
+	var sid uint64
+	if seq != 0 {
+		sid = seqKey(seq)
+	} else {
+		sid = c.getSidCallback(session, message)
+	}
 	if ch, ok := c.msgReceive.Load(sid); ok {
 		select {
 		case ch <- message.GetBody():
@@ -278,6 +318,9 @@ func (c *WsClient) checkClientConnection(clientNo int32) (sessionNo int32, err e
 	return sessionNo, nil
 }
 
+// Request method    发送请求并等待回包, 消息实现ISeqMessage时按序列号关联回包, sid仅在不使用序列号时生效
+//
+// 使用序列号时会改写消息的序列号, 同一个消息不能并发请求
 func (c *WsClient) Request(clientNo int32, sid uint64, message IMessage) (resp []byte, err error) {
 
 	sessionNo, err := c.checkClientConnection(clientNo)
@@ -285,6 +328,13 @@ func (c *WsClient) Request(clientNo int32, sid uint64, message IMessage) (resp [
 		return nil, err
 	}
 
+	if seqMessage, ok := message.(ISeqMessage); ok && !c.seqDisabled {
+		seq := c.nextSeq()
+		seqMessage.SetSeq(seq)
+		seqMessage.SetPush(false)
+		sid = seqKey(seq)
+	}
+
 	ch := make(chan []byte, 1)
 
 	c.msgReceive.Store(sid, ch)
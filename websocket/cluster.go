@@ -0,0 +1,345 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	EClusterChannelPrefix = "arkitektur/ws" // 默认频道前缀
+	EClusterRegistryTTL   = 60 * time.Second
+	EClusterCallTimeout   = 3 * time.Second
+	EClusterRedis         = "redis" // redis pub/sub总线
+	EClusterMqtt          = "mqtt"  // mqtt总线
+
+	clusterKindPush      uint8 = 1 // 转发给uid所在节点
+	clusterKindBroadcast uint8 = 2 // 全集群广播
+	clusterKindRoom      uint8 = 3 // 房间广播
+)
+
+// Backplane interface    集群消息总线, 节点间转发推送
+type Backplane interface {
+	Publish(ctx context.Context, channel string, payload []byte) error
+	Subscribe(channel string, handler func(payload []byte)) error
+	Close() error
+}
+
+// SessionRegistry interface    集群会话注册表, 记录uid所在节点
+type SessionRegistry interface {
+	Register(ctx context.Context, node string, ttl time.Duration, uids ...int32) error // 登记或续期
+	Unregister(ctx context.Context, node string, uid int32) error                      // 仅当uid仍属于node时删除
+	Lookup(ctx context.Context, uid int32) (node string, err error)                    // 不在线时返回空
+}
+
+// clusterMessage struct    节点间转发的消息
+type clusterMessage struct {
+	Kind    uint8   `json:"kind"`
+	From    string  `json:"from"`
+	Uid     int32   `json:"uid,omitempty"`
+	Room    string  `json:"room,omitempty"`
+	Exclude []int32 `json:"exclude,omitempty"`
+	MsgNo   uint32  `json:"msgNo"`
+	Body    []byte  `json:"body"`
+}
+
+// Cluster struct    集群模式, 推送本节点没有的uid时转发给所在节点, 广播扇出到全部节点
+type Cluster struct {
+	node      string
+	prefix    string
+	ttl       time.Duration
+	hub       *WsSessionHub
+	registry  SessionRegistry
+	backplane Backplane
+	stop      chan struct{}
+	stopOnce  sync.Once
+	refreshWg sync.WaitGroup // 等待续期协程退出, 避免注销后又被续期
+}
+
+// NewCluster function    新建集群模式, node为空时使用 主机名-进程号
+func NewCluster(node string, registry SessionRegistry, backplane Backplane) *Cluster {
+	if node == "" {
+		hostname, _ := os.Hostname()
+		node = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+
+	return &Cluster{
+		node:      node,
+		prefix:    EClusterChannelPrefix,
+		ttl:       EClusterRegistryTTL,
+		registry:  registry,
+		backplane: backplane,
+		stop:      make(chan struct{}),
+	}
+}
+
+// SetPrefix method    设置频道前缀, 同一总线上的多个应用需要区分
+func (c *Cluster) SetPrefix(prefix string) {
+	c.prefix = prefix
+}
+
+// SetTTL method    设置注册表过期时间, 节点异常退出后uid在过期后失效
+func (c *Cluster) SetTTL(ttl time.Duration) {
+	c.ttl = ttl
+}
+
+// Node method    当前节点id
+func (c *Cluster) Node() string {
+	return c.node
+}
+
+// nodeChannel method    节点频道
+func (c *Cluster) nodeChannel(node string) string {
+	return c.prefix + "/node/" + node
+}
+
+// broadcastChannel method    广播频道
+func (c *Cluster) broadcastChannel() string {
+	return c.prefix + "/all"
+}
+
+// start method    订阅频道并定期续期本节点的uid
+func (c *Cluster) start(hub *WsSessionHub) error {
+	c.hub = hub
+	if err := c.backplane.Subscribe(c.nodeChannel(c.node), c.receive); err != nil {
+		return err
+	}
+	if err := c.backplane.Subscribe(c.broadcastChannel(), c.receive); err != nil {
+		return err
+	}
+
+	c.refreshWg.Add(1)
+	go SafeGoRecoverWarpFunc(func() {
+		defer c.refreshWg.Done()
+		c.refreshLoop()
+	})()
+	return nil
+}
+
+// Stop method    停止续期, 从注册表注销本节点仍在线的uid并关闭总线, 其他节点不再向本节点转发
+func (c *Cluster) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+		c.refreshWg.Wait()
+
+		if c.hub != nil {
+			c.hub.sessionMap.Range(func(uid int32, _ *WsSession) bool {
+				c.unregister(uid)
+				return true
+			})
+		}
+
+		if err := c.backplane.Close(); err != nil {
+			log.Println("cluster backplane close error", err)
+		}
+	})
+}
+
+// refreshLoop method    续期本节点全部uid
+func (c *Cluster) refreshLoop() {
+	ticker := time.NewTicker(c.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			uids := make([]int32, 0)
+			c.hub.sessionMap.Range(func(uid int32, _ *WsSession) bool {
+				uids = append(uids, uid)
+				return true
+			})
+			if len(uids) == 0 {
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), EClusterCallTimeout)
+			if err := c.registry.Register(ctx, c.node, c.ttl, uids...); err != nil {
+				log.Println("cluster refresh registry error", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// register method    uid绑定到本节点
+func (c *Cluster) register(uid int32) {
+	ctx, cancel := context.WithTimeout(context.Background(), EClusterCallTimeout)
+	defer cancel()
+
+	if err := c.registry.Register(ctx, c.node, c.ttl, uid); err != nil {
+		log.Println("cluster register error", uid, err)
+	}
+}
+
+// unregister method    uid离开本节点
+func (c *Cluster) unregister(uid int32) {
+	ctx, cancel := context.WithTimeout(context.Background(), EClusterCallTimeout)
+	defer cancel()
+
+	if err := c.registry.Unregister(ctx, c.node, uid); err != nil {
+		log.Println("cluster unregister error", uid, err)
+	}
+}
+
+// forward method    转发给uid所在节点, uid不在线或在本节点时返回false
+func (c *Cluster) forward(uid int32, message IMessage) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), EClusterCallTimeout)
+	defer cancel()
+
+	node, err := c.registry.Lookup(ctx, uid)
+	if err != nil {
+		log.Println("cluster lookup error", uid, err)
+		return false
+	}
+	if node == "" || node == c.node {
+		return false
+	}
+
+	return c.publish(ctx, c.nodeChannel(node), clusterMessage{
+		Kind:  clusterKindPush,
+		Uid:   uid,
+		MsgNo: message.GetMsgNo(),
+		Body:  message.GetBody(),
+	})
+}
+
+// broadcast method    广播给其他节点
+func (c *Cluster) broadcast(message IMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), EClusterCallTimeout)
+	defer cancel()
+
+	c.publish(ctx, c.broadcastChannel(), clusterMessage{
+		Kind:  clusterKindBroadcast,
+		MsgNo: message.GetMsgNo(),
+		Body:  message.GetBody(),
+	})
+}
+
+// room method    房间消息广播给其他节点, 由各节点推送给本节点的成员
+func (c *Cluster) room(room string, message IMessage, exclude []int32) {
+	ctx, cancel := context.WithTimeout(context.Background(), EClusterCallTimeout)
+	defer cancel()
+
+	c.publish(ctx, c.broadcastChannel(), clusterMessage{
+		Kind:    clusterKindRoom,
+		Room:    room,
+		Exclude: exclude,
+		MsgNo:   message.GetMsgNo(),
+		Body:    message.GetBody(),
+	})
+}
+
+// publish method    发布到总线
+func (c *Cluster) publish(ctx context.Context, channel string, msg clusterMessage) bool {
+	msg.From = c.node
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return false
+	}
+
+	if err = c.backplane.Publish(ctx, channel, payload); err != nil {
+		log.Println("cluster publish error", channel, err)
+		return false
+	}
+	return true
+}
+
+// receive method    处理其他节点转发的消息, 只推送给本节点的会话
+func (c *Cluster) receive(payload []byte) {
+	var msg clusterMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		log.Println("cluster receive invalid message", err)
+		return
+	}
+	if msg.From == c.node {
+		return
+	}
+
+	message := &Message{MsgNo: msg.MsgNo, Body: msg.Body}
+	message.SetLength()
+
+	switch msg.Kind {
+	case clusterKindPush:
+		if !c.hub.pushLocal(msg.Uid, message) {
+			log.Println("cluster receive push for absent uid", msg.Uid, msg.From)
+		}
+	case clusterKindBroadcast:
+		c.hub.pushToAllLocal(message)
+	case clusterKindRoom:
+		c.hub.pushToRoomLocal(msg.Room, message, msg.Exclude)
+	}
+}
+
+// MemoryBackplane struct    进程内总线, 多个会话管理器共用一个实例即可模拟集群, 用于测试
+type MemoryBackplane struct {
+	mu       sync.RWMutex
+	handlers map[string][]func([]byte)
+}
+
+// NewMemoryBackplane function    新建进程内总线
+func NewMemoryBackplane() *MemoryBackplane {
+	return &MemoryBackplane{handlers: make(map[string][]func([]byte))}
+}
+
+func (b *MemoryBackplane) Publish(_ context.Context, channel string, payload []byte) error {
+	b.mu.RLock()
+	handlers := b.handlers[channel]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(payload)
+	}
+	return nil
+}
+
+func (b *MemoryBackplane) Subscribe(channel string, handler func(payload []byte)) error {
+	b.mu.Lock()
+	b.handlers[channel] = append(b.handlers[channel], handler)
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *MemoryBackplane) Close() error {
+	return nil
+}
+
+// MemoryRegistry struct    进程内会话注册表, 用于测试
+type MemoryRegistry struct {
+	mu    sync.RWMutex
+	nodes map[int32]string
+}
+
+// NewMemoryRegistry function    新建进程内会话注册表
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{nodes: make(map[int32]string)}
+}
+
+func (r *MemoryRegistry) Register(_ context.Context, node string, _ time.Duration, uids ...int32) error {
+	r.mu.Lock()
+	for _, uid := range uids {
+		r.nodes[uid] = node
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *MemoryRegistry) Unregister(_ context.Context, node string, uid int32) error {
+	r.mu.Lock()
+	if r.nodes[uid] == node {
+		delete(r.nodes, uid)
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *MemoryRegistry) Lookup(_ context.Context, uid int32) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.nodes[uid], nil
+}
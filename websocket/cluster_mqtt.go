@@ -0,0 +1,40 @@
+package websocket
+
+import (
+	"context"
+
+	"github.com/Anniext/Arkitektur/mqtt"
+)
+
+// MqttBackplane struct    基于项目mqtt客户端的集群总线, 注册表仍需使用RedisRegistry等实现
+type MqttBackplane struct {
+	client *mqtt.MQTTClient
+	topics []string
+}
+
+// NewMqttBackplane function    新建mqtt总线, 通常传入mqtt.GetDefaultMqtt()
+func NewMqttBackplane(client *mqtt.MQTTClient) *MqttBackplane {
+	return &MqttBackplane{client: client}
+}
+
+func (b *MqttBackplane) Publish(_ context.Context, channel string, payload []byte) error {
+	return b.client.Publish(channel, payload)
+}
+
+func (b *MqttBackplane) Subscribe(channel string, handler func(payload []byte)) error {
+	if err := b.client.Subscribe(channel, func(_ string, payload []byte) {
+		handler(payload)
+	}); err != nil {
+		return err
+	}
+
+	b.topics = append(b.topics, channel)
+	return nil
+}
+
+func (b *MqttBackplane) Close() error {
+	if len(b.topics) == 0 {
+		return nil
+	}
+	return b.client.Unsubscribe(b.topics...)
+}
@@ -0,0 +1,111 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const ERedisRegistryPrefix = "arkitektur:ws:uid:" // 默认注册表key前缀
+
+// unregisterScript 仅当uid仍属于该节点时删除, 避免覆盖重连到其他节点后的登记
+var unregisterScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisRegistry struct    基于redis的会话注册表, 每个uid一个带过期时间的key
+type RedisRegistry struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisRegistry function    新建redis会话注册表, 通常传入cache.GetDefaultRedis()
+func NewRedisRegistry(client redis.UniversalClient) *RedisRegistry {
+	return &RedisRegistry{client: client, prefix: ERedisRegistryPrefix}
+}
+
+// SetPrefix method    设置key前缀
+func (r *RedisRegistry) SetPrefix(prefix string) {
+	r.prefix = prefix
+}
+
+func (r *RedisRegistry) key(uid int32) string {
+	return r.prefix + strconv.FormatInt(int64(uid), 10)
+}
+
+func (r *RedisRegistry) Register(ctx context.Context, node string, ttl time.Duration, uids ...int32) error {
+	pipe := r.client.Pipeline()
+	for _, uid := range uids {
+		pipe.Set(ctx, r.key(uid), node, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisRegistry) Unregister(ctx context.Context, node string, uid int32) error {
+	return unregisterScript.Run(ctx, r.client, []string{r.key(uid)}, node).Err()
+}
+
+func (r *RedisRegistry) Lookup(ctx context.Context, uid int32) (string, error) {
+	node, err := r.client.Get(ctx, r.key(uid)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return node, err
+}
+
+// RedisBackplane struct    基于redis pub/sub的集群总线
+type RedisBackplane struct {
+	client redis.UniversalClient
+	mu     sync.Mutex
+	pubsub []*redis.PubSub
+}
+
+// NewRedisBackplane function    新建redis总线
+func NewRedisBackplane(client redis.UniversalClient) *RedisBackplane {
+	return &RedisBackplane{client: client}
+}
+
+func (b *RedisBackplane) Publish(ctx context.Context, channel string, payload []byte) error {
+	return b.client.Publish(ctx, channel, payload).Err()
+}
+
+func (b *RedisBackplane) Subscribe(channel string, handler func(payload []byte)) error {
+	pubsub := b.client.Subscribe(context.Background(), channel)
+	if _, err := pubsub.Receive(context.Background()); err != nil {
+		_ = pubsub.Close()
+		return err
+	}
+
+	b.mu.Lock()
+	b.pubsub = append(b.pubsub, pubsub)
+	b.mu.Unlock()
+
+	go SafeGoRecoverWarpFunc(func() {
+		for msg := range pubsub.Channel() {
+			handler([]byte(msg.Payload))
+		}
+	})()
+	return nil
+}
+
+func (b *RedisBackplane) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var err error
+	for _, pubsub := range b.pubsub {
+		if closeErr := pubsub.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	b.pubsub = nil
+	return err
+}
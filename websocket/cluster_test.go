@@ -0,0 +1,92 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClusterForwardAndBroadcast(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	registry := NewMemoryRegistry()
+
+	nodes := make([]*testServer, 0, 2)
+	for _, node := range []string{"a", "b"} {
+		server := NewWsServer("")
+		if err := server.SetCluster(NewCluster(node, registry, backplane)); err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, startTestServer(t, server))
+	}
+	a, b := nodes[0], nodes[1]
+	connA, _ := a.dial(t, 1)
+	connB, _ := b.dial(t, 2)
+
+	lookup := func(uid int32) string {
+		node, _ := registry.Lookup(context.Background(), uid)
+		return node
+	}
+	if lookup(1) != "a" || lookup(2) != "b" {
+		t.Fatalf("registry = %q, %q, want a, b", lookup(1), lookup(2))
+	}
+
+	tests := []struct {
+		name  string
+		send  func() bool
+		msgNo uint32   // 收到的协议号
+		conns []string // 应该收到消息的节点
+	}{
+		{name: "local push", send: func() bool { return a.Push(1, 10, []byte("local")) }, msgNo: 10, conns: []string{"a"}},
+		{name: "forward to owner", send: func() bool { return a.Push(2, 11, []byte("forward")) }, msgNo: 11, conns: []string{"b"}},
+		{name: "offline uid", send: func() bool { return a.Push(3, 12, []byte("offline")) }},
+		{name: "broadcast fans out", send: func() bool { b.PushAll(13, []byte("all")); return true }, msgNo: 13, conns: []string{"a", "b"}},
+		{name: "room fans out", send: func() bool {
+			a.Join(1, "lobby")
+			b.Join(2, "lobby")
+			return a.PushToRoom("lobby", &Message{MsgNo: 14, Body: []byte("room")}) > 0
+		}, msgNo: 14, conns: []string{"a", "b"}},
+	}
+
+	conns := map[string]*websocket.Conn{"a": connA, "b": connB}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok := tt.send(); ok != (len(tt.conns) > 0) {
+				t.Fatalf("send = %v, want %v", ok, len(tt.conns) > 0)
+			}
+			for _, name := range tt.conns {
+				if msg := readMessage(t, conns[name], BinaryCodec{}); msg.GetMsgNo() != tt.msgNo {
+					t.Errorf("%s got msgNo %d, want %d", name, msg.GetMsgNo(), tt.msgNo)
+				}
+			}
+		})
+	}
+
+	// 停止集群后注销本节点的uid, 其他节点不再转发
+	b.GetCluster().Stop()
+	if node := lookup(2); node != "" {
+		t.Errorf("uid 2 still registered on %q after Stop", node)
+	}
+	if a.Push(2, 15, []byte("dead node")) {
+		t.Error("push forwarded to a stopped node")
+	}
+}
+
+func TestServerStopsCluster(t *testing.T) {
+	registry := NewMemoryRegistry()
+	server := startTestServer(t, NewWsServer(""))
+	if err := server.SetCluster(NewCluster("a", registry, NewMemoryBackplane())); err != nil {
+		t.Fatal(err)
+	}
+	server.dial(t, 1)
+
+	server.Stop()
+	select {
+	case <-server.GetCluster().stop:
+	default:
+		t.Fatal("cluster not stopped with the server")
+	}
+	if node, _ := registry.Lookup(context.Background(), 1); node != "" {
+		t.Errorf("uid 1 still registered on %q", node)
+	}
+}
@@ -0,0 +1,329 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	ECodecBinary   = "arkitektur.binary"   // 4字节msgNo + 4字节长度 + 消息体, 二进制帧
+	ECodecJSON     = "arkitektur.json"     // {"msgNo":..,"body":..}, 文本帧
+	ECodecProtobuf = "arkitektur.protobuf" // protobuf信封 msgNo=1 body=2, 二进制帧
+)
+
+const (
+	frameFlagSeq    uint32 = 1 << 31           // 长度字段最高位: 头部后跟4字节序列号
+	frameFlagPush   uint32 = 1 << 30           // 长度字段次高位: 服务端主动推送
+	frameLengthMask        = frameFlagPush - 1 // 长度字段的有效位, MaxPacketLen小于2^30
+)
+
+// ErrInvalidFrame 帧格式错误, 读协程丢弃该帧并继续读取
+var ErrInvalidFrame = errors.New("websocket: invalid frame")
+
+// Codec interface    帧编解码, 按握手时的Sec-WebSocket-Protocol为每个连接选择
+type Codec interface {
+	Name() string   // 子协议名
+	FrameType() int // 发送使用的帧类型
+	Encode(IMessage) ([]byte, error)
+	Decode(frameType int, data []byte) (IMessage, error)
+}
+
+// invalidFrame function    帧格式错误
+func invalidFrame(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidFrame, fmt.Sprintf(format, args...))
+}
+
+// BinaryCodec struct    默认的二进制编解码, 与Message的布局一致
+//
+// 长度字段的高两位为标记位, 携带序列号时头部为 msgNo(4) + 长度(4) + seq(4), 不带标记的帧与旧格式完全一致
+type BinaryCodec struct{}
+
+func (BinaryCodec) Name() string {
+	return ECodecBinary
+}
+
+func (BinaryCodec) FrameType() int {
+	return websocket.BinaryMessage
+}
+
+func (BinaryCodec) Encode(msg IMessage) ([]byte, error) {
+	if msg == nil {
+		return nil, errors.New("message is nil")
+	}
+
+	body := msg.GetBody()
+	seq, push := seqOf(msg)
+
+	headerLen := 8
+	if seq != 0 {
+		headerLen = 12
+	}
+	length := uint32(headerLen + len(body))
+	if seq != 0 {
+		length |= frameFlagSeq
+	}
+	if push {
+		length |= frameFlagPush
+	}
+
+	msgOut := make([]byte, headerLen, headerLen+len(body))
+	binary.LittleEndian.PutUint32(msgOut[0:4], msg.GetMsgNo())
+	binary.LittleEndian.PutUint32(msgOut[4:8], length)
+	if seq != 0 {
+		binary.LittleEndian.PutUint32(msgOut[8:12], seq)
+	}
+	return append(msgOut, body...), nil
+}
+
+func (BinaryCodec) Decode(frameType int, data []byte) (IMessage, error) {
+	if frameType != websocket.BinaryMessage {
+		return nil, invalidFrame("binary codec got frame type %d", frameType)
+	}
+	if len(data) < 8 {
+		return nil, invalidFrame("short packet %d", len(data))
+	}
+
+	pm := &Message{}
+	pm.MsgNo = binary.LittleEndian.Uint32(data[0:4])
+	length := binary.LittleEndian.Uint32(data[4:8])
+	pm.Length = length & frameLengthMask
+	pm.Push = length&frameFlagPush != 0
+	if pm.Length > MaxPacketLen {
+		return nil, invalidFrame("large packet %d", pm.Length)
+	}
+
+	headerLen := 8
+	if length&frameFlagSeq != 0 {
+		if len(data) < 12 {
+			return nil, invalidFrame("short packet with seq %d", len(data))
+		}
+		pm.Seq = binary.LittleEndian.Uint32(data[8:12])
+		headerLen = 12
+	}
+	if pm.Length != uint32(len(data)) {
+		return nil, invalidFrame("length %d mismatch frame size %d", pm.Length, len(data))
+	}
+
+	pm.Body = data[headerLen:]
+	return pm, nil
+}
+
+// jsonFrame struct    json帧
+type jsonFrame struct {
+	MsgNo uint32          `json:"msgNo"`
+	Seq   uint32          `json:"seq,omitempty"`
+	Push  bool            `json:"push,omitempty"`
+	Body  json.RawMessage `json:"body,omitempty"`
+}
+
+// JSONCodec struct    json文本帧编解码
+//
+// 帧中body为json字符串时表示文本消息体, 其他json值原样作为消息体; 编码时对称处理,
+// 不是合法json或本身是json字符串的消息体按字符串发送, 保证任意消息体编解码后不变
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string {
+	return ECodecJSON
+}
+
+func (JSONCodec) FrameType() int {
+	return websocket.TextMessage
+}
+
+func (JSONCodec) Encode(msg IMessage) ([]byte, error) {
+	if msg == nil {
+		return nil, errors.New("message is nil")
+	}
+
+	frame := jsonFrame{MsgNo: msg.GetMsgNo()}
+	frame.Seq, frame.Push = seqOf(msg)
+	if body := msg.GetBody(); len(body) > 0 {
+		if json.Valid(body) && !isJSONString(body) {
+			frame.Body = body
+		} else {
+			quoted, err := json.Marshal(string(body))
+			if err != nil {
+				return nil, err
+			}
+			frame.Body = quoted
+		}
+	}
+	return json.Marshal(frame)
+}
+
+func (JSONCodec) Decode(frameType int, data []byte) (IMessage, error) {
+	if frameType != websocket.TextMessage {
+		return nil, invalidFrame("json codec got frame type %d", frameType)
+	}
+
+	var frame jsonFrame
+	if err := json.Unmarshal(data, &frame); err != nil {
+		return nil, invalidFrame("json frame: %v", err)
+	}
+
+	body := []byte(frame.Body)
+	if isJSONString(body) {
+		var text string
+		if err := json.Unmarshal(body, &text); err != nil {
+			return nil, invalidFrame("json body: %v", err)
+		}
+		body = []byte(text)
+	}
+
+	pm := &Message{MsgNo: frame.MsgNo, Seq: frame.Seq, Push: frame.Push, Body: body}
+	pm.SetLength()
+	return pm, nil
+}
+
+// isJSONString function    去掉前导空白后是否以引号开头
+func isJSONString(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return len(data) > 0 && data[0] == '"'
+}
+
+// ProtobufCodec struct    protobuf信封编解码, 等价于 message Envelope { uint32 msg_no = 1; bytes body = 2; uint32 seq = 3; bool push = 4; }
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) Name() string {
+	return ECodecProtobuf
+}
+
+func (ProtobufCodec) FrameType() int {
+	return websocket.BinaryMessage
+}
+
+func (ProtobufCodec) Encode(msg IMessage) ([]byte, error) {
+	if msg == nil {
+		return nil, errors.New("message is nil")
+	}
+
+	body := msg.GetBody()
+	buf := make([]byte, 0, len(body)+16)
+	if msgNo := msg.GetMsgNo(); msgNo != 0 {
+		buf = protowire.AppendTag(buf, 1, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, uint64(msgNo))
+	}
+	if len(body) > 0 {
+		buf = protowire.AppendTag(buf, 2, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, body)
+	}
+	seq, push := seqOf(msg)
+	if seq != 0 {
+		buf = protowire.AppendTag(buf, 3, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, uint64(seq))
+	}
+	if push {
+		buf = protowire.AppendTag(buf, 4, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, protowire.EncodeBool(true))
+	}
+	return buf, nil
+}
+
+func (ProtobufCodec) Decode(frameType int, data []byte) (IMessage, error) {
+	if frameType != websocket.BinaryMessage {
+		return nil, invalidFrame("protobuf codec got frame type %d", frameType)
+	}
+
+	pm := &Message{}
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, invalidFrame("protobuf tag: %v", protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		switch {
+		case (num == 1 || num == 3 || num == 4) && typ == protowire.VarintType:
+			v, m := protowire.ConsumeVarint(data)
+			if m < 0 {
+				return nil, invalidFrame("protobuf field %d: %v", num, protowire.ParseError(m))
+			}
+			switch num {
+			case 1:
+				pm.MsgNo = uint32(v)
+			case 3:
+				pm.Seq = uint32(v)
+			case 4:
+				pm.Push = protowire.DecodeBool(v)
+			}
+			n = m
+		case num == 2 && typ == protowire.BytesType:
+			v, m := protowire.ConsumeBytes(data)
+			if m < 0 {
+				return nil, invalidFrame("protobuf body: %v", protowire.ParseError(m))
+			}
+			pm.Body = v
+			n = m
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return nil, invalidFrame("protobuf field %d: %v", num, protowire.ParseError(n))
+			}
+		}
+		data = data[n:]
+	}
+
+	pm.SetLength()
+	return pm, nil
+}
+
+// SetCodecs method    设置可协商的编解码, 客户端未指定子协议时仍使用SetMessage设置的包结构
+func (hub *WsSessionHub) SetCodecs(codecs ...Codec) {
+	hub.codecs = make(map[string]Codec, len(codecs))
+	for _, codec := range codecs {
+		hub.codecs[codec.Name()] = codec
+	}
+}
+
+// negotiateCodec method    按客户端提供的子协议顺序选择编解码
+func (hub *WsSessionHub) negotiateCodec(protocols []string) string {
+	for _, protocol := range protocols {
+		if _, ok := hub.codecs[protocol]; ok {
+			return protocol
+		}
+	}
+	return ""
+}
+
+// getCodec method    连接使用的编解码, 没有协商时为nil
+func (hub *WsSessionHub) getCodec(protocol string) Codec {
+	if protocol == "" {
+		return nil
+	}
+	return hub.codecs[protocol]
+}
+
+// frameCache struct    扇出时按编解码缓存编码结果, 每种编解码只编码一次
+type frameCache struct {
+	message IMessage
+	frames  map[string][]byte
+}
+
+// newFrameCache function    新建编码缓存
+func newFrameCache(message IMessage) *frameCache {
+	return &frameCache{message: message, frames: make(map[string][]byte, 1)}
+}
+
+// get method    会话对应的编码结果, 按编解码与是否带推送标记区分
+func (c *frameCache) get(session *WsSession) ([]byte, error) {
+	name := session.Codec()
+	if session.pushFlagged() {
+		name += "/push"
+	}
+	if frame, ok := c.frames[name]; ok {
+		return frame, nil
+	}
+
+	frame, err := session.encodePush(c.message)
+	if err != nil {
+		return nil, err
+	}
+	c.frames[name] = frame
+	return frame, nil
+}
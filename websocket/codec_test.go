@@ -0,0 +1,165 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// binaryFrame function    按BinaryCodec布局拼接帧, length为长度字段的原始值
+func binaryFrame(msgNo, length uint32, rest ...byte) []byte {
+	frame := make([]byte, 8, 8+len(rest))
+	binary.LittleEndian.PutUint32(frame[0:4], msgNo)
+	binary.LittleEndian.PutUint32(frame[4:8], length)
+	return append(frame, rest...)
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	codecs := []Codec{BinaryCodec{}, JSONCodec{}, ProtobufCodec{}}
+	messages := []struct {
+		name string
+		msg  *Message
+	}{
+		{name: "empty body", msg: &Message{MsgNo: 1}},
+		{name: "binary body", msg: &Message{MsgNo: 2, Body: []byte{0, 1, 0xff}}},
+		{name: "json object", msg: &Message{MsgNo: 3, Body: []byte(`{"a":1}`)}},
+		{name: "json string", msg: &Message{MsgNo: 4, Body: []byte(`"quoted"`)}},
+		{name: "plain text", msg: &Message{MsgNo: 5, Body: []byte(`hello`)}},
+		{name: "with seq", msg: &Message{MsgNo: 6, Seq: 42, Body: []byte(`{}`)}},
+		{name: "push", msg: &Message{MsgNo: 7, Push: true, Body: []byte(`[1]`)}},
+	}
+
+	for _, codec := range codecs {
+		for _, tt := range messages {
+			t.Run(codec.Name()+"/"+tt.name, func(t *testing.T) {
+				if codec.Name() == ECodecJSON && !isText(tt.msg.Body) {
+					t.Skip("json codec carries text bodies only")
+				}
+
+				data, err := codec.Encode(tt.msg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				decoded, err := codec.Decode(codec.FrameType(), data)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got := decoded.(*Message)
+				want := *tt.msg
+				want.SetLength()
+				if got.MsgNo != want.MsgNo || got.Seq != want.Seq || got.Push != want.Push ||
+					got.Length != want.Length || !bytes.Equal(got.Body, want.Body) {
+					t.Errorf("decoded = %+v, want %+v", *got, want)
+				}
+			})
+		}
+	}
+}
+
+// isText function    是否为可见ascii文本, json编解码按字符串携带非json消息体
+func isText(body []byte) bool {
+	for _, b := range body {
+		if b < 0x20 || b >= 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCodecDecodeInvalid(t *testing.T) {
+	seqFrame := binaryFrame(1, 12|frameFlagSeq, 1, 0, 0, 0)
+	tests := []struct {
+		name      string
+		codec     Codec
+		frameType int
+		data      []byte
+	}{
+		{"binary wrong frame type", BinaryCodec{}, websocket.TextMessage, binaryFrame(1, 8)},
+		{"binary short header", BinaryCodec{}, websocket.BinaryMessage, []byte{1, 0, 0, 0, 8}},
+		{"binary seq flag without seq", BinaryCodec{}, websocket.BinaryMessage, binaryFrame(1, 12|frameFlagSeq)},
+		{"binary oversize", BinaryCodec{}, websocket.BinaryMessage, binaryFrame(1, MaxPacketLen+1)},
+		{"binary length shorter than frame", BinaryCodec{}, websocket.BinaryMessage, binaryFrame(1, 8, 'x')},
+		{"binary length longer than frame", BinaryCodec{}, websocket.BinaryMessage, binaryFrame(1, 20, 'x')},
+		{"binary seq length mismatch", BinaryCodec{}, websocket.BinaryMessage, append(seqFrame, 'x')},
+		{"json wrong frame type", JSONCodec{}, websocket.BinaryMessage, []byte(`{"msgNo":1}`)},
+		{"json malformed", JSONCodec{}, websocket.TextMessage, []byte(`{"msgNo":`)},
+		{"json bad msgNo", JSONCodec{}, websocket.TextMessage, []byte(`{"msgNo":"1"}`)},
+		{"protobuf wrong frame type", ProtobufCodec{}, websocket.TextMessage, nil},
+		{"protobuf truncated tag", ProtobufCodec{}, websocket.BinaryMessage, []byte{0x80}},
+		{"protobuf truncated body", ProtobufCodec{}, websocket.BinaryMessage,
+			protowire.AppendVarint(protowire.AppendTag(nil, 2, protowire.BytesType), 10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.codec.Decode(tt.frameType, tt.data); !errors.Is(err, ErrInvalidFrame) {
+				t.Errorf("err = %v, want ErrInvalidFrame", err)
+			}
+		})
+	}
+}
+
+func TestBinaryCodecFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		want   Message
+		length uint32
+	}{
+		{"plain", binaryFrame(9, 10, 'h', 'i'), Message{MsgNo: 9, Length: 10, Body: []byte("hi")}, 10},
+		{"seq", binaryFrame(9, 14|frameFlagSeq, 5, 0, 0, 0, 'h', 'i'), Message{MsgNo: 9, Length: 14, Seq: 5, Body: []byte("hi")}, 14},
+		{"push", binaryFrame(9, 8|frameFlagPush), Message{MsgNo: 9, Length: 8, Push: true, Body: []byte{}}, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := BinaryCodec{}.Decode(websocket.BinaryMessage, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := *msg.(*Message); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decoded = %+v, want %+v", got, tt.want)
+			}
+
+			encoded, err := BinaryCodec{}.Encode(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(encoded, tt.data) {
+				t.Errorf("re-encoded = %v, want %v", encoded, tt.data)
+			}
+		})
+	}
+}
+
+func TestJSONCodecBody(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+		body  string
+	}{
+		{"object kept raw", `{"msgNo":1,"body":{"a":1}}`, `{"a":1}`},
+		{"number kept raw", `{"msgNo":1,"body":12}`, `12`},
+		{"string unquoted", `{"msgNo":1,"body":"hello"}`, `hello`},
+		{"escaped string", `{"msgNo":1,"body":"\"quoted\""}`, `"quoted"`},
+		{"missing body", `{"msgNo":1}`, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := JSONCodec{}.Decode(websocket.TextMessage, []byte(tt.frame))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(msg.GetBody()); got != tt.body {
+				t.Errorf("body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
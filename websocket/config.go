@@ -1,8 +1,24 @@
 package websocket
 
 type WebsocketConfig struct {
-	Port        int
-	TimeoutRead int
+	Port         int
+	Path         string // 不为空时挂载到server.GetDefaultGin()的该路由, 不再单独监听Port, 需要在server.InitDefaultGin之前初始化
+	TimeoutRead  int
+	AllowOrigins []string // origin白名单, 为空不校验
+	AuthEnable   bool     // 是否开启握手鉴权
+	AuthRequired bool     // 握手时是否必须携带token
+	Cluster      string   // 集群总线, redis或mqtt, 为空时只推送本节点; 注册表固定使用redis
+	Node         string   // 集群节点id, 为空时使用 主机名-进程号
+	PingInterval int      // ping间隔秒数, 为0时不发送ping
+	PingMissed   int      // 连续未响应的ping次数上限, 超过后踢出会话
+	Heartbeat    uint32   // 应用层心跳协议号, 为0时关闭
+	QueueLen     int      // 会话写队列消息数上限, 为0时不限制
+	QueueBytes   int      // 会话写队列字节数上限, 为0时不限制
+	QueuePolicy  string   // 写队列超限策略: drop_oldest、drop_newest、disconnect
+	TimeoutWrite int      // 写超时秒数
+	Workers      int      // 执行协议回调的协程池大小, 为0时在读协程中执行
+	TimeoutProto int      // 协议回调超时秒数, 超时回复ErrCodeTimeOut
+	ReadLimit    int64    // 单帧读取上限(字节), 为0时使用EDefaultReadLimit
 }
 type Option func(*WebsocketConfig)
 
@@ -12,12 +28,78 @@ func WithPortOption(port int) Option {
 	}
 }
 
+func WithPathOption(path string) Option {
+	return func(c *WebsocketConfig) {
+		c.Path = path
+	}
+}
+
 func WithTimeoutReadOption(timeoutRead int) Option {
 	return func(c *WebsocketConfig) {
 		c.TimeoutRead = timeoutRead
 	}
 }
 
+func WithAllowOriginsOption(allowOrigins ...string) Option {
+	return func(c *WebsocketConfig) {
+		c.AllowOrigins = allowOrigins
+	}
+}
+
+func WithAuthOption(enable, required bool) Option {
+	return func(c *WebsocketConfig) {
+		c.AuthEnable = enable
+		c.AuthRequired = required
+	}
+}
+
+func WithClusterOption(cluster, node string) Option {
+	return func(c *WebsocketConfig) {
+		c.Cluster = cluster
+		c.Node = node
+	}
+}
+
+func WithPingOption(interval, missed int) Option {
+	return func(c *WebsocketConfig) {
+		c.PingInterval = interval
+		c.PingMissed = missed
+	}
+}
+
+func WithHeartbeatOption(msgNo uint32) Option {
+	return func(c *WebsocketConfig) {
+		c.Heartbeat = msgNo
+	}
+}
+
+func WithWriteQueueOption(maxLen, maxBytes int, policy string) Option {
+	return func(c *WebsocketConfig) {
+		c.QueueLen = maxLen
+		c.QueueBytes = maxBytes
+		c.QueuePolicy = policy
+	}
+}
+
+func WithTimeoutWriteOption(timeoutWrite int) Option {
+	return func(c *WebsocketConfig) {
+		c.TimeoutWrite = timeoutWrite
+	}
+}
+
+func WithWorkersOption(workers, timeoutProto int) Option {
+	return func(c *WebsocketConfig) {
+		c.Workers = workers
+		c.TimeoutProto = timeoutProto
+	}
+}
+
+func WithReadLimitOption(readLimit int64) Option {
+	return func(c *WebsocketConfig) {
+		c.ReadLimit = readLimit
+	}
+}
+
 func NewWebsocketOption(options ...Option) {
 	defaultWebsocketConfig = &WebsocketConfig{}
 	for _, option := range options {
@@ -1,8 +1,14 @@
 package websocket
 
 import (
+	"errors"
 	"fmt"
+	"github.com/Anniext/Arkitektur/cache"
+	"github.com/Anniext/Arkitektur/mqtt"
+	"github.com/Anniext/Arkitektur/server"
 	"github.com/Anniext/Arkitektur/system/log"
+	"github.com/gin-gonic/gin"
+	"github.com/panjf2000/ants/v2"
 	"time"
 )
 
@@ -13,10 +19,53 @@ func InitDefaultWebsocket() error {
 
 	addr := fmt.Sprintf(":%d", cnf.Port)
 	defaultWebsocket = NewWsServer(addr)
+	defaultWebsocket.Use(LoggerMiddleware(), RecoveryMiddleware())
 
 	// 暂时硬编码满足大部分情况， 如不能满足转到配置文件
-	timeoutRead := time.Second * time.Duration(cnf.TimeoutRead)
-	defaultWebsocket.WsSessionHub.SetTimeoutRead(timeoutRead)
+	if cnf.TimeoutRead > 0 {
+		timeoutRead := time.Second * time.Duration(cnf.TimeoutRead)
+		defaultWebsocket.WsSessionHub.SetTimeoutRead(timeoutRead)
+	}
+	if cnf.TimeoutWrite > 0 {
+		defaultWebsocket.WsSessionHub.SetTimeoutWrite(time.Second * time.Duration(cnf.TimeoutWrite))
+	}
+	defaultWebsocket.SetWriteQueue(cnf.QueueLen, cnf.QueueBytes, cnf.QueuePolicy)
+	defaultWebsocket.SetReadLimit(cnf.ReadLimit)
+	if cnf.Workers > 0 {
+		pool, err := ants.NewPool(cnf.Workers)
+		if err != nil {
+			return err
+		}
+		defaultWebsocket.SetPool(pool)
+		defaultWebsocket.AtClose(pool.Release)
+	}
+	defaultWebsocket.SetHandlerTimeout(time.Second * time.Duration(cnf.TimeoutProto))
+	defaultWebsocket.SetPing(time.Second*time.Duration(cnf.PingInterval), cnf.PingMissed)
+	defaultWebsocket.SetHeartbeat(cnf.Heartbeat)
+	defaultWebsocket.SetAllowOrigins(cnf.AllowOrigins...)
+	if cnf.AuthEnable {
+		defaultWebsocket.SetAuth(NewWsAuth(cnf.AuthRequired))
+	}
+
+	if cnf.Cluster != "" {
+		if err := initDefaultCluster(cnf); err != nil {
+			return err
+		}
+	}
+
+	if cnf.Path != "" {
+		// 与http接口共用端口、TLS与中间件, 需要在server.InitDefaultGin之前调用, 路由在开始监听前挂载
+		handler := defaultWebsocket.GinHandler()
+		if err := server.RegisterEngine(func(engine *gin.Engine) {
+			engine.GET(cnf.Path, handler)
+		}); err != nil {
+			return fmt.Errorf("websocket: mount %s: %w", cnf.Path, err)
+		}
+		// 没有单独监听, gin服务Shutdown时关闭会话并执行退出回调
+		server.RegisterOnShutdown(defaultWebsocket.Close)
+		log.Info("websocket mount on:", cnf.Path)
+		return nil
+	}
 
 	log.Info("server start in:", addr)
 	go SafeGoRecoverWarpFunc(func() {
@@ -26,6 +75,29 @@ func InitDefaultWebsocket() error {
 	return nil
 }
 
+// initDefaultCluster function    使用默认redis与mqtt客户端开启集群模式
+func initDefaultCluster(cnf *WebsocketConfig) error {
+	client := cache.GetDefaultRedis()
+	if client == nil {
+		return errors.New("websocket: cluster registry requires cache.InitDefaultRedis")
+	}
+
+	var backplane Backplane
+	switch cnf.Cluster {
+	case EClusterRedis:
+		backplane = NewRedisBackplane(client)
+	case EClusterMqtt:
+		if mqtt.GetDefaultMqtt() == nil {
+			return errors.New("websocket: mqtt backplane requires mqtt.InitDefaultMqtt")
+		}
+		backplane = NewMqttBackplane(mqtt.GetDefaultMqtt())
+	default:
+		return fmt.Errorf("websocket: unknown cluster backplane %q", cnf.Cluster)
+	}
+
+	return defaultWebsocket.SetCluster(NewCluster(cnf.Node, NewRedisRegistry(client), backplane))
+}
+
 func GetDefaultWebsocket() *WsServer {
 	return defaultWebsocket
 }
@@ -0,0 +1,279 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/panjf2000/ants/v2"
+)
+
+// SetPool method    协议回调提交到共享协程池执行, 同一会话的回调与PushWork按到达顺序依次执行
+//
+// pool为nil时在读协程中同步执行, 只对之后建立的会话生效
+func (hub *WsSessionHub) SetPool(pool *ants.Pool) {
+	hub.pool = pool
+}
+
+// SetHandlerTimeout method    协议回调超时时间, 超时后立即回复ErrCodeTimeOut, 回调之后的返回值被丢弃
+func (hub *WsSessionHub) SetHandlerTimeout(timeout time.Duration) {
+	hub.handlerTimeout = timeout
+}
+
+// SetConcurrency method    限制msgNo在全部会话上同时执行的回调数, n小于1时不限制
+//
+// 运行中修改只对之后到达的协议生效, 没有空位时会话的任务暂停并让出协程池的协程, 空位释放后按等待顺序继续
+func (hub *WsSessionHub) SetConcurrency(msgNo uint32, n int) {
+	hub.msgLimitMu.Lock()
+	defer hub.msgLimitMu.Unlock()
+
+	limits := make(map[uint32]*msgLimit)
+	if current := hub.msgLimits.Load(); current != nil {
+		for key, limit := range *current {
+			limits[key] = limit
+		}
+	}
+	if n < 1 {
+		delete(limits, msgNo)
+	} else {
+		limits[msgNo] = newMsgLimit(n)
+	}
+	hub.msgLimits.Store(&limits)
+}
+
+// limitOf method    msgNo的并发上限, 没有限制时为nil
+func (hub *WsSessionHub) limitOf(msgNo uint32) *msgLimit {
+	if limits := hub.msgLimits.Load(); limits != nil {
+		return (*limits)[msgNo]
+	}
+	return nil
+}
+
+// msgLimit struct    协议并发上限, 没有空位时登记等待者, 空位释放后直接转交给最早的等待者
+type msgLimit struct {
+	mu      sync.Mutex
+	max     int
+	running int
+	waiters []func() bool // 返回false表示等待者已放弃, 空位转交给下一个
+}
+
+// newMsgLimit function    新建并发上限
+func newMsgLimit(n int) *msgLimit {
+	return &msgLimit{max: n}
+}
+
+// tryAcquire method    占用空位, 没有空位时登记wake, 空位转交时调用
+func (l *msgLimit) tryAcquire(wake func() bool) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.running < l.max {
+		l.running++
+		return true
+	}
+	l.waiters = append(l.waiters, wake)
+	return false
+}
+
+// acquire method    阻塞占用空位, 只用于没有协程池时的读协程
+func (l *msgLimit) acquire(ctx context.Context) bool {
+	var state int32 // 0等待 1已转交 2已放弃
+	ready := make(chan struct{})
+	wake := func() bool {
+		if atomic.CompareAndSwapInt32(&state, 0, 1) {
+			close(ready)
+			return true
+		}
+		return false
+	}
+	if l.tryAcquire(wake) {
+		return true
+	}
+
+	select {
+	case <-ready:
+		return true
+	case <-ctx.Done():
+		if !atomic.CompareAndSwapInt32(&state, 0, 2) {
+			// 放弃的同时空位已经转交过来, 归还
+			l.release()
+		}
+		return false
+	}
+}
+
+// release method    释放空位, 有等待者时转交给等待者
+func (l *msgLimit) release() {
+	for {
+		l.mu.Lock()
+		if len(l.waiters) == 0 {
+			l.running--
+			l.mu.Unlock()
+			return
+		}
+		wake := l.waiters[0]
+		l.waiters[0] = nil
+		l.waiters = l.waiters[1:]
+		l.mu.Unlock()
+
+		if wake() {
+			return
+		}
+	}
+}
+
+// sessionTask struct    会话等待执行的任务
+type sessionTask struct {
+	run   func()
+	limit *msgLimit // 协议并发上限, 为nil时不限制
+	held  bool      // 已占用limit的空位
+}
+
+// Ctx method    回调中为本次调用的context, 带有超时; 其他地方为会话的context, 会话关闭时取消
+func (s *WsSession) Ctx() context.Context {
+	if ctx := s.callCtx.Load(); ctx != nil {
+		return *ctx
+	}
+	return s.ctx
+}
+
+// dispatch method    按顺序执行会话的任务, limit为任务的并发上限, 没有协程池时直接执行
+func (s *WsSession) dispatch(task func(), limit *msgLimit) {
+	if s.hub.pool == nil {
+		if limit != nil {
+			if !limit.acquire(s.ctx) {
+				return
+			}
+			defer limit.release()
+		}
+		SafeGoRecoverWarpFunc(task)()
+		return
+	}
+
+	s.taskMu.Lock()
+	s.tasks = append(s.tasks, &sessionTask{run: task, limit: limit})
+	if s.taskRunning {
+		s.taskMu.Unlock()
+		return
+	}
+	s.taskRunning = true
+	s.taskMu.Unlock()
+
+	s.submit()
+}
+
+// submit method    把会话的任务队列提交到协程池
+func (s *WsSession) submit() {
+	if err := s.hub.pool.Submit(s.runTasks); err != nil {
+		log.Println("handler pool submit fail, run inline", s.RemoteAddr(), err)
+		s.runTasks()
+	}
+}
+
+// runTasks method    取出会话的任务依次执行, 队列为空或等待并发空位时退出
+func (s *WsSession) runTasks() {
+	for {
+		s.taskMu.Lock()
+		if len(s.tasks) == 0 {
+			s.taskRunning = false
+			s.taskMu.Unlock()
+			return
+		}
+		task := s.tasks[0]
+		s.taskMu.Unlock()
+
+		if task.limit != nil && !task.held {
+			wake := func() bool {
+				task.held = true
+				s.submit()
+				return true
+			}
+			if !task.limit.tryAcquire(wake) {
+				// 没有空位时让出协程, 队列保持运行状态, 空位转交后重新提交
+				return
+			}
+			task.held = true
+		}
+
+		s.taskMu.Lock()
+		s.tasks[0] = nil
+		s.tasks = s.tasks[1:]
+		s.taskMu.Unlock()
+
+		SafeGoRecoverWarpFunc(task.run)()
+		if task.held {
+			task.limit.release()
+		}
+	}
+}
+
+// handle method    执行协议回调并回包, 回包带回请求的序列号
+func (s *WsSession) handle(fn ProtoFunc, msg IMessage) {
+	if s.ctx.Err() != nil {
+		return
+	}
+
+	seq, _ := seqOf(msg)
+	if seq != 0 {
+		atomic.StoreInt32(&s.peerSeq, 1)
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var replied int32
+	if timeout := s.hub.handlerTimeout; timeout > 0 {
+		ctx, cancel = context.WithTimeout(s.ctx, timeout)
+		timer := time.AfterFunc(timeout, func() {
+			if atomic.CompareAndSwapInt32(&replied, 0, 1) {
+				log.Println("handler timeout", s.RemoteAddr(), msg.GetMsgNo())
+				s.reply(msg.GetMsgNo(), seq, s.ErrorReply(code.ErrCodeTimeOut))
+			}
+		})
+		defer timer.Stop()
+	} else {
+		ctx, cancel = context.WithCancel(s.ctx)
+	}
+	defer cancel()
+
+	s.callCtx.Store(&ctx)
+	body := fn(s, msg)
+	s.callCtx.Store(nil)
+
+	if atomic.CompareAndSwapInt32(&replied, 0, 1) {
+		s.reply(msg.GetMsgNo(), seq, body)
+	}
+
+	if s.msg != nil {
+		msgStr, err := s.encodePush(s.msg)
+		if err != nil {
+			log.Println("post msg: ", s.RemoteAddr(), err)
+		} else {
+			s.sendMsg(msgStr)
+		}
+		s.msg = nil
+	}
+}
+
+// reply method    回包, body为nil时不回复
+func (s *WsSession) reply(msgNo, seq uint32, body []byte) {
+	if body == nil {
+		return
+	}
+
+	outMsg := &Message{
+		MsgNo: msgNo,
+		Seq:   seq,
+		Body:  body,
+	}
+	outMsg.SetLength()
+
+	msgStr, err := s.encode(outMsg)
+	if err != nil {
+		log.Println("proto handler", s.RemoteAddr(), err)
+		return
+	}
+	s.sendMsg(msgStr)
+}
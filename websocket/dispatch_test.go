@@ -0,0 +1,180 @@
+package websocket
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+func TestHandleCallContext(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		wantDeadline bool
+	}{
+		{name: "no timeout", timeout: 0, wantDeadline: false},
+		{name: "with timeout", timeout: time.Minute, wantDeadline: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := &WsSessionHub{}
+			hub.Init()
+			hub.SetHandlerTimeout(tt.timeout)
+
+			session := &WsSession{hub: hub, writeQueue: NewMessageQueue()}
+			session.ctx, session.cancel = context.WithCancel(context.Background())
+			defer session.cancel()
+
+			var callCtx context.Context
+			session.handle(func(s *WsSession, _ IMessage) []byte {
+				callCtx = s.Ctx()
+				if callCtx == s.ctx {
+					t.Error("handler got the session context, want a per-call context")
+				}
+				return nil
+			}, &Message{MsgNo: 1})
+
+			if _, ok := callCtx.Deadline(); ok != tt.wantDeadline {
+				t.Errorf("deadline set = %v, want %v", ok, tt.wantDeadline)
+			}
+			if callCtx.Err() == nil {
+				t.Error("call context not cancelled after handle returned")
+			}
+			if session.Ctx() != session.ctx {
+				t.Error("Ctx outside handler should be the session context")
+			}
+		})
+	}
+}
+
+func TestMsgLimit(t *testing.T) {
+	limit := newMsgLimit(1)
+	if !limit.tryAcquire(nil) {
+		t.Fatal("first acquire failed")
+	}
+
+	// 放弃的等待者被跳过, 空位转交给下一个
+	var woken []string
+	limit.tryAcquire(func() bool { woken = append(woken, "gone"); return false })
+	limit.tryAcquire(func() bool { woken = append(woken, "next"); return true })
+
+	limit.release()
+	if strings.Join(woken, ",") != "gone,next" || limit.running != 1 {
+		t.Fatalf("woken = %v, running = %d, want gone,next and the slot handed over", woken, limit.running)
+	}
+	limit.release()
+	if limit.running != 0 {
+		t.Errorf("running = %d after last release, want 0", limit.running)
+	}
+
+	// 阻塞等待时取消
+	limit.tryAcquire(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if limit.acquire(ctx) {
+		t.Error("acquire with a cancelled context succeeded")
+	}
+	limit.release()
+	if limit.running != 0 || len(limit.waiters) != 0 {
+		t.Errorf("running = %d, waiters = %d, want 0, 0", limit.running, len(limit.waiters))
+	}
+}
+
+func TestDispatchConcurrencyYieldsWorker(t *testing.T) {
+	pool, err := ants.NewPool(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Release()
+
+	hub := &WsSessionHub{}
+	hub.Init()
+	hub.SetPool(pool)
+	hub.SetConcurrency(1, 1)
+
+	newSession := func() *WsSession {
+		session := &WsSession{hub: hub, writeQueue: NewMessageQueue()}
+		session.ctx, session.cancel = context.WithCancel(context.Background())
+		t.Cleanup(session.cancel)
+		return session
+	}
+	a, b, c := newSession(), newSession(), newSession()
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func() {
+		return func() {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}
+	}
+
+	// a占用唯一的空位, b等待空位时不能占住协程池的协程
+	unblock := make(chan struct{})
+	started := make(chan struct{})
+	a.dispatch(func() { close(started); <-unblock }, hub.limitOf(1))
+	<-started
+	b.dispatch(record("b limited"), hub.limitOf(1))
+	b.dispatch(record("b after"), hub.limitOf(2))
+
+	done := make(chan struct{})
+	c.dispatch(func() { close(done) }, hub.limitOf(2))
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("unlimited task starved, a pool worker is parked on the limit")
+	}
+
+	close(unblock)
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(order)
+		mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(order, ",") != "b limited,b after" {
+		t.Errorf("order = %v, want b limited then b after", order)
+	}
+}
+
+func TestSetConcurrencyWhileRunning(t *testing.T) {
+	hub := &WsSessionHub{}
+	hub.Init()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			hub.SetConcurrency(uint32(i%4), i%3)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			hub.limitOf(uint32(i % 4))
+		}
+	}()
+	wg.Wait()
+
+	hub.SetConcurrency(1, 2)
+	hub.SetConcurrency(2, 0)
+	if limit := hub.limitOf(1); limit == nil || limit.max != 2 {
+		t.Errorf("limit for 1 = %+v, want max 2", limit)
+	}
+	if hub.limitOf(2) != nil {
+		t.Error("limit for 2 not removed")
+	}
+}
@@ -0,0 +1,65 @@
+package websocket
+
+import (
+	"encoding/json"
+
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/Anniext/Arkitektur/i18n"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ErrorBody struct    协议处理失败时回复的消息体, 与http的CodeApi结构一致
+//
+// protobuf子协议下等价于 message Reply { int32 code = 1; string message = 2; bytes data = 3; }
+type ErrorBody struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Locale method    会话的语言, 依次取token声明、握手的lang参数、Accept-Language
+func (s *WsSession) Locale() string {
+	return i18n.LocaleFromRequest(s.Request, s.GetClaims())
+}
+
+// ErrorReply method    生成本地化的错误回复, 协议回调直接返回即可
+func (s *WsSession) ErrorReply(errCode code.IErrCode) []byte {
+	var data []byte
+	if e, ok := errCode.(*code.Error); ok && len(e.Details) > 0 && s.Codec() != ECodecProtobuf {
+		data, _ = json.Marshal(e.Details)
+	}
+	return s.envelope(errCode, "", data)
+}
+
+// envelope method    按会话的编解码生成 {code, message, data} 回包, message为空时使用错误码的本地化消息
+func (s *WsSession) envelope(errCode code.IErrCode, message string, data []byte) []byte {
+	if message == "" {
+		message = i18n.Message(s.Locale(), errCode)
+	}
+
+	if s.Codec() == ECodecProtobuf {
+		buf := protowire.AppendTag(nil, 1, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, uint64(errCode.Int64()))
+		buf = protowire.AppendTag(buf, 2, protowire.BytesType)
+		buf = protowire.AppendString(buf, message)
+		if len(data) > 0 {
+			buf = protowire.AppendTag(buf, 3, protowire.BytesType)
+			buf = protowire.AppendBytes(buf, data)
+		}
+		return buf
+	}
+
+	body := ErrorBody{
+		Code:    errCode.Int32(),
+		Message: message,
+	}
+	if len(data) > 0 {
+		body.Data = json.RawMessage(data)
+	}
+
+	reply, err := json.Marshal(body)
+	if err != nil {
+		return nil
+	}
+	return reply
+}
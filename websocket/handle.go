@@ -0,0 +1,116 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/Anniext/Arkitektur/server"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoRegister interface    可以注册协议回调的路由, WsSessionHub、WsServer、RouteGroup都满足
+type ProtoRegister interface {
+	Register(msgNo uint32, fn ProtoFunc, middleware ...Middleware) error
+}
+
+// HandlerFunc 类型化的协议回调, 返回NoErrCode时回复resp, 否则回复错误码
+type HandlerFunc[Req any, Resp any] func(ctx context.Context, session *WsSession, req *Req) (*Resp, code.ErrCode)
+
+// Handle function    注册类型化的协议回调, 按会话的编解码解析请求体并校验binding标签, 回包统一为 {code, message, data}
+//
+// Req、Resp实现proto.Message时, protobuf子协议下使用protobuf编码, 其余子协议使用protojson, 未实现时使用json
+func Handle[Req any, Resp any](router ProtoRegister, msgNo uint32, handler HandlerFunc[Req, Resp], middleware ...Middleware) error {
+	server.GetValidator() // 注册时初始化校验器的字段名与翻译
+	return router.Register(msgNo, func(session *WsSession, message IMessage) []byte {
+		req := new(Req)
+		if err := session.unmarshalBody(message.GetBody(), req); err != nil {
+			return session.invalidParams(err)
+		}
+
+		if err := binding.Validator.ValidateStruct(req); err != nil {
+			return session.invalidParams(err)
+		}
+
+		resp, errCode := handler(session.Ctx(), session, req)
+		if errCode != code.NoErrCode && errCode != code.Success {
+			return session.ErrorReply(errCode)
+		}
+
+		var data []byte
+		if resp != nil {
+			var err error
+			if data, err = session.marshalBody(resp); err != nil {
+				log.Println("handle marshal reply", session.RemoteAddr(), msgNo, err)
+				return session.ErrorReply(code.ErrCodeOfflineReasonHandlerPanic)
+			}
+		}
+		return session.envelope(code.Success, "", data)
+	}, middleware...)
+}
+
+// unmarshalBody method    按会话的编解码解析请求体, 空请求体保持零值
+func (s *WsSession) unmarshalBody(body []byte, v any) error {
+	if len(body) == 0 {
+		return nil
+	}
+
+	m, ok := v.(proto.Message)
+	switch {
+	case !ok:
+		return json.Unmarshal(body, v)
+	case s.Codec() == ECodecProtobuf:
+		return proto.Unmarshal(body, m)
+	default:
+		return protojson.Unmarshal(body, m)
+	}
+}
+
+// marshalBody method    按会话的编解码编码回包数据
+func (s *WsSession) marshalBody(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	switch {
+	case !ok:
+		return json.Marshal(v)
+	case s.Codec() == ECodecProtobuf:
+		return proto.Marshal(m)
+	default:
+		return protojson.Marshal(m)
+	}
+}
+
+// invalidParams method    参数错误, json回包在data中返回字段级错误
+func (s *WsSession) invalidParams(err error) []byte {
+	locale := s.Locale()
+
+	var fieldErrs []server.FieldError
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fieldErrs = server.TranslateValidationErrors(validationErrs, locale)
+	} else {
+		fieldErr := server.FieldError{Tag: "type", Message: err.Error()}
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			fieldErr.Field = typeErr.Field
+		}
+		fieldErrs = []server.FieldError{fieldErr}
+	}
+
+	// protobuf回包的data是业务消息, 字段错误拼接到message中
+	if s.Codec() == ECodecProtobuf {
+		messages := make([]string, 0, len(fieldErrs))
+		for _, fieldErr := range fieldErrs {
+			messages = append(messages, fieldErr.Message)
+		}
+		return s.envelope(code.ErrCodeInvalidParams, strings.Join(messages, "; "), nil)
+	}
+
+	data, _ := json.Marshal(fieldErrs)
+	return s.envelope(code.ErrCodeInvalidParams, "", data)
+}
@@ -0,0 +1,114 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Anniext/Arkitektur/code"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type echoReq struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func TestHandleCodecs(t *testing.T) {
+	router := NewRouter()
+	if err := Handle(router, 1, func(_ context.Context, _ *WsSession, req *echoReq) (*echoReq, code.ErrCode) {
+		return req, code.NoErrCode
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Handle(router, 2, func(_ context.Context, _ *WsSession, req *structpb.Struct) (*structpb.Struct, code.ErrCode) {
+		return req, code.NoErrCode
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	protoBody, _ := proto.Marshal(&structpb.Struct{Fields: map[string]*structpb.Value{"name": structpb.NewStringValue("a")}})
+
+	tests := []struct {
+		name     string
+		codec    Codec
+		msgNo    uint32
+		body     []byte
+		wantCode code.ErrCode
+		wantData string
+	}{
+		{"json struct", JSONCodec{}, 1, []byte(`{"name":"a"}`), code.Success, `{"name":"a"}`},
+		{"json validation", JSONCodec{}, 1, []byte(`{}`), code.ErrCodeInvalidParams, ""},
+		{"json type error", JSONCodec{}, 1, []byte(`{"name":1}`), code.ErrCodeInvalidParams, ""},
+		{"json session uses protojson", JSONCodec{}, 2, []byte(`{"name":"a"}`), code.Success, `{"name":"a"}`},
+		{"binary session uses protojson", BinaryCodec{}, 2, []byte(`{"name":"a"}`), code.Success, `{"name":"a"}`},
+		{"protobuf session uses protobuf", ProtobufCodec{}, 2, protoBody, code.Success, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &WsSession{codec: tt.codec}
+			session.ctx = context.Background()
+			fn, _ := router.lookup(tt.msgNo)
+			reply := fn(session, &Message{MsgNo: tt.msgNo, Body: tt.body})
+
+			if tt.codec.Name() == ECodecProtobuf {
+				// 信封 code=1, message=2, data=3, data为Resp的protobuf编码
+				resp := &structpb.Struct{}
+				data := protoField(t, reply, 3)
+				if err := proto.Unmarshal(data, resp); err != nil {
+					t.Fatal(err)
+				}
+				if resp.Fields["name"].GetStringValue() != "a" {
+					t.Errorf("protobuf reply = %v", resp)
+				}
+				return
+			}
+
+			var body struct {
+				Code int32           `json:"code"`
+				Data json.RawMessage `json:"data"`
+			}
+			if err := json.Unmarshal(reply, &body); err != nil {
+				t.Fatalf("reply %s: %v", reply, err)
+			}
+			if code.ErrCode(body.Code) != tt.wantCode {
+				t.Errorf("code = %d, want %d: %s", body.Code, tt.wantCode, reply)
+			}
+			if tt.wantData != "" && compactJSON(t, body.Data) != tt.wantData {
+				t.Errorf("data = %s, want %s", body.Data, tt.wantData)
+			}
+		})
+	}
+}
+
+// protoField function    取出protobuf编码中的bytes字段
+func protoField(t *testing.T, data []byte, field protowire.Number) []byte {
+	t.Helper()
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		data = data[n:]
+		if num == field && typ == protowire.BytesType {
+			v, _ := protowire.ConsumeBytes(data)
+			return v
+		}
+		data = data[protowire.ConsumeFieldValue(num, typ, data):]
+	}
+	t.Fatalf("field %d not found", field)
+	return nil
+}
+
+// compactJSON function    去掉空白, protojson的输出带有随机空格
+func compactJSON(t *testing.T, data []byte) string {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	if err := json.Compact(buf, data); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
@@ -4,7 +4,10 @@ import (
 	"log"
 	"net/http"
 	"sync/atomic"
+	"time"
 
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 )
 
@@ -14,12 +17,73 @@ type WsHandler struct {
 }
 
 func (h *WsHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
-	if conn, err := h.upgrade.Upgrade(resp, req, nil); err != nil {
+	h.serve(resp, req, nil)
+}
+
+// serve method    鉴权并升级连接, header为握手响应需要额外携带的头
+func (h *WsHandler) serve(resp http.ResponseWriter, req *http.Request, header http.Header) {
+	req, responseHeader, ok := h.server.authenticate(resp, req)
+	if !ok {
+		return
+	}
+
+	if header == nil {
+		header = http.Header{}
+	}
+	for key, values := range responseHeader {
+		header[key] = values
+	}
+
+	// 子协议只能回显一个, 协商到编解码时优先回显编解码
+	if protocol := h.server.negotiateCodec(websocket.Subprotocols(req)); protocol != "" {
+		header.Set("Sec-Websocket-Protocol", protocol)
+	}
+
+	if conn, err := h.upgrade.Upgrade(batchResponseWriter{resp}, req, header); err != nil {
 		return
 	} else {
-		wsSession := NewWsSession(&h.server.WsSessionHub, conn, req)
+		wsSession := newWsSession(&h.server.WsSessionHub, conn, req)
 		h.server.sessions.Store(wsSession, true)
 		atomic.AddInt32(&h.server.sessionNum, 1)
 		log.Println("incoming connnetion ", wsSession.ClientIP())
+
+		// 先绑定uid再开始读取, 首个协议到达时GetUid已经可用
+		if errCode := h.server.bindUid(wsSession); errCode != code.NoErrCode {
+			log.Println("bind uid fail, session close", wsSession.ClientIP(), errCode.String())
+			closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, errCode.String())
+			conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+			wsSession.close(false)
+		}
+		wsSession.start()
+	}
+}
+
+// Handler method    握手处理, 可挂载到任意http路由上
+func (s *WsServer) Handler() http.Handler {
+	return s.handler
+}
+
+// GinHandler method    挂载到gin路由的握手处理, 与其他接口共用中间件
+//
+// 中间件写入的"claims"视为已鉴权, 不再校验token; 中间件设置的响应头(如X-Request-ID)随握手响应返回
+func (s *WsServer) GinHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := ctx.Request
+		if value, ok := ctx.Get(EGinClaimsKey); ok {
+			if claims, ok := value.(map[string]any); ok && claims != nil {
+				req = withClaims(req, claims)
+			}
+		}
+
+		header := http.Header{}
+		for key, values := range ctx.Writer.Header() {
+			if key == "Sec-Websocket-Extensions" || key == "Content-Type" {
+				continue
+			}
+			header[key] = values
+		}
+
+		s.handler.serve(ctx.Writer, req, header)
+		ctx.Abort()
 	}
 }
@@ -0,0 +1,181 @@
+package websocket
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func TestGinHandlerBindsUidBeforeFirstFrame(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := NewWsServer("")
+	server.SetAuth(NewWsAuth(false))
+	uids := make(chan int32, 1)
+	if err := server.Register(1, func(session *WsSession, message IMessage) []byte {
+		uids <- session.GetUid()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := gin.New()
+	engine.GET("/ws", func(ctx *gin.Context) {
+		ctx.Set(EGinClaimsKey, map[string]any{EAuthUidClaim: float64(42)})
+	}, server.GinHandler())
+	ts := httptest.NewServer(engine)
+	defer ts.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	frame, _ := BinaryCodec{}.Encode(&Message{MsgNo: 1})
+	if err = conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case uid := <-uids:
+		if uid != 42 {
+			t.Fatalf("uid = %d, want 42", uid)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler not called")
+	}
+}
+
+func TestGinHandlerRejectsUid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := NewWsServer("")
+	server.SetAuth(NewWsAuth(false))
+
+	engine := gin.New()
+	engine.GET("/ws", func(ctx *gin.Context) {
+		uid, _ := strconv.ParseFloat(ctx.Query("uid"), 64)
+		ctx.Set(EGinClaimsKey, map[string]any{EAuthUidClaim: uid})
+	}, server.GinHandler())
+	ts := httptest.NewServer(engine)
+	defer ts.Close()
+
+	dial := func(t *testing.T, uid string) *websocket.Conn {
+		t.Helper()
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws?uid="+uid, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+
+	online := dial(t, "7")
+	defer online.Close()
+	deadline := time.Now().Add(time.Second)
+	for server.GetSession(7) == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	tests := []struct {
+		name string
+		uid  string
+		want code.ErrCode
+	}{
+		{name: "above int32", uid: "4294967303", want: code.ErrCodeJwtClaimsInvalid},
+		{name: "below int32", uid: "-4294967303", want: code.ErrCodeJwtClaimsInvalid},
+		{name: "already online", uid: "7", want: code.EErrCodeRepeatedLogin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := dial(t, tt.uid)
+			defer conn.Close()
+
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			_, _, err := conn.ReadMessage()
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) {
+				t.Fatalf("read = %v, want close frame", err)
+			}
+			if closeErr.Code != websocket.ClosePolicyViolation || closeErr.Text != tt.want.String() {
+				t.Errorf("close = %d %q, want %d %q", closeErr.Code, closeErr.Text, websocket.ClosePolicyViolation, tt.want.String())
+			}
+		})
+	}
+
+	if session := server.GetSession(7); session == nil {
+		t.Error("online session for uid 7 was replaced")
+	}
+}
+
+// testServer struct    挂在httptest上的ws服务
+type testServer struct {
+	*WsServer
+	url string
+}
+
+// startTestServer function    在httptest上启动ws服务, 测试结束时关闭
+func startTestServer(t *testing.T, server *WsServer) *testServer {
+	t.Helper()
+
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+	return &testServer{WsServer: server, url: "ws" + strings.TrimPrefix(ts.URL, "http")}
+}
+
+// dial method    建立连接并在服务端绑定uid, uid为0时不绑定
+func (s *testServer) dial(t *testing.T, uid int32, subprotocols ...string) (*websocket.Conn, *WsSession) {
+	t.Helper()
+
+	dialer := websocket.Dialer{Subprotocols: subprotocols}
+	conn, _, err := dialer.Dial(s.url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	// 新会话是唯一没有绑定uid的会话
+	var session *WsSession
+	deadline := time.Now().Add(time.Second)
+	for session == nil && time.Now().Before(deadline) {
+		s.sessions.Range(func(key *WsSession, _ bool) bool {
+			if key.GetUid() == 0 && !key.Dead() {
+				session = key
+				return false
+			}
+			return true
+		})
+		time.Sleep(time.Millisecond)
+	}
+	if session == nil {
+		t.Fatal("session not created")
+	}
+	if uid != 0 && !session.SetUid(uid) {
+		t.Fatalf("bind uid %d fail", uid)
+	}
+	return conn, session
+}
+
+// readMessage function    读取并解码一帧
+func readMessage(t *testing.T, conn *websocket.Conn, codec Codec) IMessage {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	frameType, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := codec.Decode(frameType, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const EDefaultPingMaxMissed = 3
+
+// SetPing method    开启ping保活, 每interval发送一次ping, 连续maxMissed次没有收到pong或任何消息时踢出会话
+//
+// interval为0时关闭, maxMissed小于1时使用EDefaultPingMaxMissed, 只对之后建立的会话生效
+func (hub *WsSessionHub) SetPing(interval time.Duration, maxMissed int) {
+	if maxMissed < 1 {
+		maxMissed = EDefaultPingMaxMissed
+	}
+	hub.pingInterval = interval
+	hub.pingMaxMissed = int32(maxMissed)
+}
+
+// SetHeartbeat method    应用层心跳协议号, 供会剥离控制帧的代理后面的客户端使用
+//
+// 收到该协议时原样回包, 注册了该协议的回调时交给回调处理, 为0时关闭
+func (hub *WsSessionHub) SetHeartbeat(msgNo uint32) {
+	hub.heartbeatMsgNo = msgNo
+}
+
+// keepalive method    注册pong回调并启动ping协程
+func (s *WsSession) keepalive() {
+	s.conn.SetPongHandler(func(string) error {
+		s.alive()
+		return s.conn.SetReadDeadline(time.Now().Add(s.timeoutRead))
+	})
+
+	if s.hub.pingInterval <= 0 {
+		return
+	}
+
+	go SafeGoRecoverWarpFunc(func() {
+		ticker := time.NewTicker(s.hub.pingInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if s.Dead() {
+				return
+			}
+
+			if atomic.AddInt32(&s.missedPongs, 1) > s.hub.pingMaxMissed {
+				log.Println("ping timeout, session evicted", s.ClientIP())
+				s.close(false)
+				return
+			}
+
+			deadline := time.Now().Add(s.hub.pingInterval)
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				log.Println("ping fail, session evicted", s.ClientIP(), err)
+				s.close(false)
+				return
+			}
+		}
+	})()
+}
+
+// alive method    收到pong或任何消息, 清空未响应的ping计数
+func (s *WsSession) alive() {
+	atomic.StoreInt32(&s.missedPongs, 0)
+}
+
+// heartbeat method    应用层心跳的回包
+func (s *WsSession) heartbeat(msg IMessage) {
+	body := msg.GetBody()
+	if body == nil {
+		body = []byte{}
+	}
+
+	seq, _ := seqOf(msg)
+	s.reply(msg.GetMsgNo(), seq, body)
+}
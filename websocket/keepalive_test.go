@@ -0,0 +1,105 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestKeepalive(t *testing.T) {
+	const interval = 20 * time.Millisecond
+
+	tests := []struct {
+		name        string
+		maxMissed   int
+		timeoutRead time.Duration
+		answer      bool          // 客户端是否回复pong
+		wait        time.Duration // 观察时长
+		wantDead    bool
+	}{
+		// 第3次tick时踢出, 读超时不会先到
+		{name: "evicted after missed pongs", maxMissed: 2, timeoutRead: time.Minute, wait: 20 * interval, wantDead: true},
+		// 不会因为漏pong踢出, 读超时小于观察时长, 只有pong延长读超时时会话才能存活
+		{name: "pong extends read deadline", maxMissed: 100, timeoutRead: 5 * interval, answer: true, wait: 20 * interval},
+		{name: "read deadline without pong", maxMissed: 100, timeoutRead: 5 * interval, wait: 20 * interval, wantDead: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewWsServer("")
+			server.SetPing(interval, tt.maxMissed)
+			server.SetTimeoutRead(tt.timeoutRead)
+			ts := startTestServer(t, server)
+
+			conn, session := ts.dial(t, 1)
+			if !tt.answer {
+				conn.SetPingHandler(func(string) error { return nil })
+			}
+
+			// 客户端只读不写, 控制帧在读取时处理
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				for {
+					if _, _, err := conn.ReadMessage(); err != nil {
+						return
+					}
+				}
+			}()
+
+			select {
+			case <-closed:
+			case <-time.After(tt.wait):
+			}
+
+			if session.Dead() != tt.wantDead {
+				t.Fatalf("session dead = %v, want %v", session.Dead(), tt.wantDead)
+			}
+			if tt.wantDead {
+				select {
+				case <-closed:
+				case <-time.After(time.Second):
+					t.Fatal("client connection not closed after eviction")
+				}
+				if ts.GetSession(1) != nil {
+					t.Error("evicted session still bound to uid 1")
+				}
+			}
+		})
+	}
+}
+
+func TestKeepaliveMessageResetsMissed(t *testing.T) {
+	const interval = 20 * time.Millisecond
+
+	server := NewWsServer("")
+	server.SetPing(interval, 2)
+	server.SetHeartbeat(99)
+	ts := startTestServer(t, server)
+
+	conn, session := ts.dial(t, 1)
+	conn.SetPingHandler(func(string) error { return nil })
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// 不回复pong, 但持续发送应用层心跳
+	buf, err := BinaryCodec{}.Encode(&Message{MsgNo: 99})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for end := time.Now().Add(20 * interval); time.Now().Before(end); time.Sleep(interval / 2) {
+		if err := conn.WriteMessage(websocket.BinaryMessage, buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if session.Dead() {
+		t.Error("session evicted although messages kept arriving")
+	}
+}
@@ -1,15 +1,14 @@
 package websocket
 
 import (
-	"encoding/binary"
-	"errors"
-	"fmt"
-
 	"github.com/gorilla/websocket"
 )
 
 const MaxPacketLen uint32 = 1024 * 1024 * 1000
 
+// EDefaultReadLimit 默认的单帧读取上限, 超过时连接被关闭
+const EDefaultReadLimit int64 = 4 * 1024 * 1024
+
 type IMessage interface {
 	GetMsgNo() uint32
 	GetLength() uint32
@@ -20,9 +19,19 @@ type IMessage interface {
 	Encode(IMessage) ([]byte, error)
 }
 
+// ISeqMessage interface    携带序列号的消息, 服务端回包原样带回序列号, 主动推送带推送标记
+type ISeqMessage interface {
+	GetSeq() uint32
+	SetSeq(uint32)
+	IsPush() bool
+	SetPush(bool)
+}
+
 type Message struct {
 	MsgNo  uint32
 	Length uint32
+	Seq    uint32 // 请求序列号, 为0时不携带
+	Push   bool   // 服务端主动推送, 不是请求的回包
 	Body   []byte
 }
 
@@ -48,6 +57,41 @@ func (p *Message) SetLength() {
 	}
 
 	p.Length = uint32(8) + uint32(len(p.Body))
+	if p.Seq != 0 {
+		p.Length += 4
+	}
+}
+
+func (p *Message) GetSeq() uint32 {
+	if p == nil {
+		return 0
+	}
+
+	return p.Seq
+}
+
+func (p *Message) SetSeq(seq uint32) {
+	if p == nil {
+		return
+	}
+
+	p.Seq = seq
+}
+
+func (p *Message) IsPush() bool {
+	if p == nil {
+		return false
+	}
+
+	return p.Push
+}
+
+func (p *Message) SetPush(push bool) {
+	if p == nil {
+		return
+	}
+
+	p.Push = push
 }
 
 func (p *Message) SetBody(bytes []byte) {
@@ -67,49 +111,38 @@ func (p *Message) GetBody() []byte {
 }
 
 func (p *Message) Decode(conn *websocket.Conn) (IMessage, error) {
-	_, dataReader, err := conn.ReadMessage()
-
+	frameType, data, err := conn.ReadMessage()
 	if err != nil {
-		// todo 调试
 		return nil, err
 	}
 
-	pm := &Message{}
-
-	// 前四位是消息号
-	pm.MsgNo = uint32(dataReader[0]&0xff) + uint32(dataReader[1]&0xff)<<8 + uint32(dataReader[2]&0xff)<<16 + uint32(dataReader[3]&0xff)<<24
-
-	// 后两位是长度
-	pm.Length = uint32(dataReader[4]&0xff) + uint32(dataReader[5]&0xff)<<8 + uint32(dataReader[6]&0xff)<<16 + uint32(dataReader[7]&0xff)<<24
-	if pm.Length > MaxPacketLen {
-
-		return nil, errors.New(fmt.Sprintf("large packet %d", pm.Length))
-	}
-
-	// 后面的是消息
-	pm.Body = dataReader[8:]
-
-	return pm, nil
+	return BinaryCodec{}.Decode(frameType, data)
 }
 
 func (p *Message) Encode(msg IMessage) ([]byte, error) {
-	if msg == nil {
-		return nil, errors.New("message is nil")
-	}
-
-	var body []byte
-	body = msg.GetBody()
+	return BinaryCodec{}.Encode(msg)
+}
 
-	var l = len(body)
-	var buf [4]byte
-	var msgOut []byte
+// pushMessage struct    推送时附加推送标记, 不修改原消息以便共享编码结果
+type pushMessage struct {
+	IMessage
+}
 
-	binary.LittleEndian.PutUint32(buf[:], msg.GetMsgNo())
-	msgOut = append(msgOut, buf[:]...)
+func (p pushMessage) GetSeq() uint32 {
+	return 0
+}
 
-	binary.LittleEndian.PutUint32(buf[:], uint32(l+8))
-	msgOut = append(msgOut, buf[:]...)
+func (p pushMessage) IsPush() bool {
+	return true
+}
 
-	msgOut = append(msgOut, body...)
-	return msgOut, nil
+// seqOf function    消息的序列号与推送标记, 不支持序列号的消息返回零值
+func seqOf(msg IMessage) (seq uint32, push bool) {
+	if m, ok := msg.(interface {
+		GetSeq() uint32
+		IsPush() bool
+	}); ok {
+		return m.GetSeq(), m.IsPush()
+	}
+	return 0, false
 }
@@ -6,18 +6,86 @@ import (
 
 const EMessageQueueDefaultCap = 4
 
+const (
+	EQueueDropOldest = "drop_oldest" // 队列满时丢弃最早的消息
+	EQueueDropNewest = "drop_newest" // 队列满时丢弃新消息
+	EQueueDisconnect = "disconnect"  // 队列满时断开连接
+)
+
 type MessageQueue struct {
 	list     [][]byte
 	mu       sync.Mutex
 	listCond *sync.Cond
+	maxLen   int    // 消息数上限, 为0时不限制
+	maxBytes int    // 字节数上限, 为0时不限制
+	policy   string // 超过上限时的策略
+	bytes    int    // 队列中的字节数
+	dropped  uint64 // 累计丢弃的消息数
 }
 
-func (queue *MessageQueue) Add(msg []byte) {
+// SetLimit method    设置队列上限与超限策略, policy为空时使用EQueueDropOldest
+func (queue *MessageQueue) SetLimit(maxLen, maxBytes int, policy string) {
+	if policy == "" {
+		policy = EQueueDropOldest
+	}
+
 	queue.mu.Lock()
+	queue.maxLen = maxLen
+	queue.maxBytes = maxBytes
+	queue.policy = policy
+	queue.mu.Unlock()
+}
+
+// Add method    追加消息, 超过上限且策略不是EQueueDropOldest时返回false, nil为退出标记不受上限限制
+func (queue *MessageQueue) Add(msg []byte) bool {
+	queue.mu.Lock()
+	if msg != nil && queue.full(len(msg)) {
+		if queue.policy != EQueueDropOldest {
+			queue.dropped++
+			queue.mu.Unlock()
+			return false
+		}
+
+		for len(queue.list) > 0 && queue.list[0] != nil && queue.full(len(msg)) {
+			queue.bytes -= len(queue.list[0])
+			queue.list[0] = nil
+			queue.list = queue.list[1:]
+			queue.dropped++
+		}
+	}
 	queue.list = append(queue.list, msg)
+	queue.bytes += len(msg)
 	queue.mu.Unlock()
 
 	queue.listCond.Signal()
+	return true
+}
+
+// full method    再加入size字节后是否超过上限, 空队列总能放入一条消息
+func (queue *MessageQueue) full(size int) bool {
+	if len(queue.list) == 0 {
+		return false
+	}
+	if queue.maxLen > 0 && len(queue.list)+1 > queue.maxLen {
+		return true
+	}
+	return queue.maxBytes > 0 && queue.bytes+size > queue.maxBytes
+}
+
+// Depth method    队列中的消息数与字节数
+func (queue *MessageQueue) Depth() (messages, bytes int) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	return len(queue.list), queue.bytes
+}
+
+// Dropped method    累计丢弃的消息数
+func (queue *MessageQueue) Dropped() uint64 {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	return queue.dropped
 }
 
 func (queue *MessageQueue) Reset() {
@@ -28,6 +96,7 @@ func (queue *MessageQueue) Reset() {
 
 func (queue *MessageQueue) reset() {
 	queue.list = queue.list[0:0]
+	queue.bytes = 0
 }
 
 func (queue *MessageQueue) Pick(retList *[][]byte) bool {
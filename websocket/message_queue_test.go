@@ -0,0 +1,117 @@
+package websocket
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMessageQueueLimit(t *testing.T) {
+	msg := func(b byte, n int) []byte { return bytes.Repeat([]byte{b}, n) }
+
+	tests := []struct {
+		name     string
+		maxLen   int
+		maxBytes int
+		policy   string
+		adds     [][]byte
+		accepted []bool
+		want     [][]byte
+		dropped  uint64
+	}{
+		{
+			name:     "unlimited",
+			adds:     [][]byte{msg('a', 1), msg('b', 1), msg('c', 1)},
+			accepted: []bool{true, true, true},
+			want:     [][]byte{msg('a', 1), msg('b', 1), msg('c', 1)},
+		},
+		{
+			name:     "drop oldest by count",
+			maxLen:   2,
+			adds:     [][]byte{msg('a', 1), msg('b', 1), msg('c', 1)},
+			accepted: []bool{true, true, true},
+			want:     [][]byte{msg('b', 1), msg('c', 1)},
+			dropped:  1,
+		},
+		{
+			name:     "drop oldest by bytes",
+			maxBytes: 4,
+			policy:   EQueueDropOldest,
+			adds:     [][]byte{msg('a', 2), msg('b', 2), msg('c', 3)},
+			accepted: []bool{true, true, true},
+			want:     [][]byte{msg('c', 3)},
+			dropped:  2,
+		},
+		{
+			name:     "drop newest",
+			maxLen:   2,
+			policy:   EQueueDropNewest,
+			adds:     [][]byte{msg('a', 1), msg('b', 1), msg('c', 1)},
+			accepted: []bool{true, true, false},
+			want:     [][]byte{msg('a', 1), msg('b', 1)},
+			dropped:  1,
+		},
+		{
+			name:     "disconnect",
+			maxBytes: 3,
+			policy:   EQueueDisconnect,
+			adds:     [][]byte{msg('a', 2), msg('b', 2)},
+			accepted: []bool{true, false},
+			want:     [][]byte{msg('a', 2)},
+			dropped:  1,
+		},
+		{
+			name:     "oversize message fits empty queue",
+			maxBytes: 2,
+			policy:   EQueueDropNewest,
+			adds:     [][]byte{msg('a', 5)},
+			accepted: []bool{true},
+			want:     [][]byte{msg('a', 5)},
+		},
+		{
+			name:     "exit marker ignores limit",
+			maxLen:   1,
+			policy:   EQueueDropNewest,
+			adds:     [][]byte{msg('a', 1), nil},
+			accepted: []bool{true, true},
+			want:     [][]byte{msg('a', 1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := NewMessageQueue()
+			queue.SetLimit(tt.maxLen, tt.maxBytes, tt.policy)
+
+			for idx, data := range tt.adds {
+				if got := queue.Add(data); got != tt.accepted[idx] {
+					t.Errorf("Add #%d = %v, want %v", idx, got, tt.accepted[idx])
+				}
+			}
+			if got := queue.Dropped(); got != tt.dropped {
+				t.Errorf("Dropped = %d, want %d", got, tt.dropped)
+			}
+
+			wantBytes := 0
+			for _, data := range tt.want {
+				wantBytes += len(data)
+			}
+			if _, depthBytes := queue.Depth(); depthBytes != wantBytes {
+				t.Errorf("Depth bytes = %d, want %d", depthBytes, wantBytes)
+			}
+
+			var picked [][]byte
+			queue.Pick(&picked)
+			if len(picked) != len(tt.want) {
+				t.Fatalf("picked %q, want %q", picked, tt.want)
+			}
+			for idx := range picked {
+				if !bytes.Equal(picked[idx], tt.want[idx]) {
+					t.Errorf("picked[%d] = %q, want %q", idx, picked[idx], tt.want[idx])
+				}
+			}
+			if messages, depthBytes := queue.Depth(); messages != 0 || depthBytes != 0 {
+				t.Errorf("Depth after Pick = %d/%d, want empty", messages, depthBytes)
+			}
+		})
+	}
+}
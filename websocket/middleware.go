@@ -0,0 +1,188 @@
+package websocket
+
+import (
+	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Anniext/Arkitektur/code"
+	"github.com/Anniext/Arkitektur/system/log"
+	"go.uber.org/zap"
+)
+
+// RecoveryMiddleware function    捕获回调panic, 回复ErrCodeOfflineReasonHandlerPanic
+func RecoveryMiddleware() Middleware {
+	return func(protoFunc ProtoFunc) ProtoFunc {
+		return func(session *WsSession, message IMessage) (reply []byte) {
+			defer func() {
+				err := recover()
+				if err == nil {
+					return
+				}
+
+				log.GetLogger().Error("ws panic recovered",
+					zap.Uint32("msgNo", message.GetMsgNo()),
+					zap.Int32("uid", session.GetUid()),
+					zap.String("ip", session.RemoteAddr()),
+					zap.Any("error", err),
+					zap.ByteString("stack", debug.Stack()),
+				)
+				reply = session.ErrorReply(code.ErrCodeOfflineReasonHandlerPanic)
+			}()
+
+			return protoFunc(session, message)
+		}
+	}
+}
+
+// LoggerMiddleware function    通过system/log记录协议访问日志
+func LoggerMiddleware() Middleware {
+	return func(protoFunc ProtoFunc) ProtoFunc {
+		return func(session *WsSession, message IMessage) []byte {
+			start := time.Now()
+			reply := protoFunc(session, message)
+
+			seq, _ := seqOf(message)
+			log.GetLogger().Info("ws access",
+				zap.Uint32("msgNo", message.GetMsgNo()),
+				zap.Uint32("seq", seq),
+				zap.Int32("uid", session.GetUid()),
+				zap.String("ip", session.RemoteAddr()),
+				zap.Int("size", len(message.GetBody())),
+				zap.Int("replySize", len(reply)),
+				zap.Duration("latency", time.Since(start)),
+			)
+			return reply
+		}
+	}
+}
+
+// tokenBucket struct    令牌桶
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// allow method    取一个令牌
+func (b *tokenBucket) allow(rate float64, burst int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * rate
+		if b.tokens > float64(burst) {
+			b.tokens = float64(burst)
+		}
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// RateLimitMiddleware function    按会话限流, 每秒rate个请求, 允许burst个突发, 超过时回复ErrCodeTooManyRequests
+//
+// 每次调用都会新建独立的令牌桶, 用在分组上时分组内的协议共用一个令牌桶
+func RateLimitMiddleware(rate float64, burst int) Middleware {
+	if burst < 1 {
+		burst = 1
+	}
+	key := new(byte) // 令牌桶在会话Context中的key
+
+	return func(protoFunc ProtoFunc) ProtoFunc {
+		return func(session *WsSession, message IMessage) []byte {
+			value, _ := session.Context.LoadOrStore(key, &tokenBucket{})
+			if !value.(*tokenBucket).allow(rate, burst) {
+				return session.ErrorReply(code.ErrCodeTooManyRequests)
+			}
+			return protoFunc(session, message)
+		}
+	}
+}
+
+// ProtoStat struct    单个协议的统计
+type ProtoStat struct {
+	MsgNo      uint32        // 协议号
+	Count      int64         // 调用次数
+	InFlight   int64         // 正在执行的次数
+	TotalTime  time.Duration // 累计耗时
+	MaxTime    time.Duration // 最大耗时
+	ReplyBytes int64         // 累计回包字节数
+}
+
+// protoCounter struct    协议计数
+type protoCounter struct {
+	count      int64
+	inFlight   int64
+	totalTime  int64
+	maxTime    int64
+	replyBytes int64
+}
+
+// ProtoMetrics struct    协议调用指标
+type ProtoMetrics struct {
+	counters sync.Map // msgNo -> *protoCounter
+}
+
+// NewProtoMetrics function    新建协议调用指标
+func NewProtoMetrics() *ProtoMetrics {
+	return &ProtoMetrics{}
+}
+
+// Middleware method    统计调用次数与耗时的中间件
+func (m *ProtoMetrics) Middleware() Middleware {
+	return func(protoFunc ProtoFunc) ProtoFunc {
+		return func(session *WsSession, message IMessage) []byte {
+			value, _ := m.counters.LoadOrStore(message.GetMsgNo(), &protoCounter{})
+			counter := value.(*protoCounter)
+
+			atomic.AddInt64(&counter.inFlight, 1)
+			start := time.Now()
+			defer func() {
+				elapsed := int64(time.Since(start))
+				atomic.AddInt64(&counter.inFlight, -1)
+				atomic.AddInt64(&counter.count, 1)
+				atomic.AddInt64(&counter.totalTime, elapsed)
+				for {
+					old := atomic.LoadInt64(&counter.maxTime)
+					if elapsed <= old || atomic.CompareAndSwapInt64(&counter.maxTime, old, elapsed) {
+						break
+					}
+				}
+			}()
+
+			reply := protoFunc(session, message)
+			atomic.AddInt64(&counter.replyBytes, int64(len(reply)))
+			return reply
+		}
+	}
+}
+
+// GetMetrics method    获取各协议的统计, 按msgNo升序
+func (m *ProtoMetrics) GetMetrics() []ProtoStat {
+	stats := make([]ProtoStat, 0)
+	m.counters.Range(func(key, value any) bool {
+		counter := value.(*protoCounter)
+		stats = append(stats, ProtoStat{
+			MsgNo:      key.(uint32),
+			Count:      atomic.LoadInt64(&counter.count),
+			InFlight:   atomic.LoadInt64(&counter.inFlight),
+			TotalTime:  time.Duration(atomic.LoadInt64(&counter.totalTime)),
+			MaxTime:    time.Duration(atomic.LoadInt64(&counter.maxTime)),
+			ReplyBytes: atomic.LoadInt64(&counter.replyBytes),
+		})
+		return true
+	})
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].MsgNo < stats[j].MsgNo })
+	return stats
+}
@@ -1,3 +1,6 @@
 package websocket
 
 type ProtoFunc func(*WsSession, IMessage) []byte
+
+// Middleware 协议中间件, 包装回调后返回新的回调
+type Middleware func(ProtoFunc) ProtoFunc
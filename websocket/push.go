@@ -2,58 +2,80 @@ package websocket
 
 import (
 	"log"
+	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/Anniext/Arkitektur/common"
+	"github.com/panjf2000/ants/v2"
 )
 
+var _ common.Publisher = (*WsSessionHub)(nil)
+
 type WsSessionHub struct {
-	sessions             *MapWsSessionBool                     // 建立连接的会话
-	sessionNum           int32                                 // 建立连接的数量
-	sessionMap           MapInt32WsSession                     // 并发map, key是msgNo, value是连接
-	sessionExitFunctions []func(*WsSession, int32)             // 会话对应的回调
-	protoFunctions       map[uint32]ProtoFunc                  // 协议对应的回调
-	timeoutCloseRead     time.Duration                         // 关闭等待时间
-	timeoutWrite         time.Duration                         // 写包超时时间
-	timeoutRead          time.Duration                         // 读包超时时间
-	message              IMessage                              // 包结构
-	UnregisteredCallback ProtoFunc                             // 未注册的回调函数
-	middleware           []func(protoFunc ProtoFunc) ProtoFunc // 中间件
-	certFile             string                                // 安全证书
-	keyFile              string                                // 安全密钥
-	ForwardedByClientIP  bool                                  // 白名单
+	sessions             *MapWsSessionBool                    // 建立连接的会话
+	sessionNum           int32                                // 建立连接的数量
+	sessionMap           MapInt32WsSession                    // 并发map, key是msgNo, value是连接
+	sessionExitFunctions []func(*WsSession, int32)            // 会话对应的回调
+	router               *Router                              // 协议路由
+	timeoutCloseRead     time.Duration                        // 关闭等待时间
+	timeoutWrite         time.Duration                        // 写包超时时间
+	timeoutRead          time.Duration                        // 读包超时时间
+	message              IMessage                             // 包结构
+	UnregisteredCallback ProtoFunc                            // 未注册的回调函数
+	certFile             string                               // 安全证书
+	keyFile              string                               // 安全密钥
+	ForwardedByClientIP  bool                                 // 白名单
+	cluster              *Cluster                             // 集群模式, 为空时只推送本节点
+	rooms                *roomSet                             // 房间
+	codecs               map[string]Codec                     // 可协商的编解码, key为子协议
+	markPush             bool                                 // 服务端主动推送附加推送标记
+	pingInterval         time.Duration                        // ping间隔, 为0时不发送
+	pingMaxMissed        int32                                // 连续未响应的ping次数上限
+	heartbeatMsgNo       uint32                               // 应用层心跳协议号, 为0时关闭
+	writeQueueLen        int                                  // 会话写队列消息数上限, 为0时不限制
+	writeQueueBytes      int                                  // 会话写队列字节数上限, 为0时不限制
+	writeQueuePolicy     string                               // 写队列超限策略
+	readLimit            int64                                // 单帧读取上限
+	pool                 *ants.Pool                           // 执行协议回调的协程池, 为空时在读协程中执行
+	handlerTimeout       time.Duration                        // 协议回调超时时间, 为0时不限制
+	msgLimits            atomic.Pointer[map[uint32]*msgLimit] // 协议并发上限, 变更时整体替换
+	msgLimitMu           sync.Mutex                           // 修改msgLimits的锁
 }
 
 // Init 初始化
 func (hub *WsSessionHub) Init() {
-	hub.protoFunctions = make(map[uint32]ProtoFunc)
+	hub.router = NewRouter()
 	hub.sessions = &MapWsSessionBool{}
 	hub.timeoutCloseRead = 0
 	hub.timeoutWrite = 5 * time.Minute
 	hub.timeoutRead = 5 * time.Minute
+	hub.readLimit = EDefaultReadLimit
 	hub.message = &Message{}
 	hub.ForwardedByClientIP = true
+	hub.rooms = newRoomSet()
+	hub.msgLimits.Store(&map[uint32]*msgLimit{})
+	hub.AtSessionClose(hub.leaveRoomsAtClose)
 }
 
-// Use method    使用中间件
-func (hub *WsSessionHub) Use(middleware func(protoFunc ProtoFunc) ProtoFunc) {
-	hub.middleware = append(hub.middleware, middleware)
+// Use method    添加全局中间件, 对已注册的协议同样生效
+func (hub *WsSessionHub) Use(middleware ...Middleware) {
+	hub.router.Use(middleware...)
 }
 
-// Register method    注册
-func (hub *WsSessionHub) Register(msgNo uint32, fn ProtoFunc) {
-	if fn == nil {
-		return
-	}
+// Register method    注册协议回调, middleware只作用于该协议, msgNo已注册时返回ErrDuplicateMsgNo
+func (hub *WsSessionHub) Register(msgNo uint32, fn ProtoFunc, middleware ...Middleware) error {
+	return hub.router.Register(msgNo, fn, middleware...)
+}
 
-	_, ok := hub.protoFunctions[msgNo]
-	if ok {
-		return
-	} else {
-		for idx := range hub.middleware {
-			fn = hub.middleware[idx](fn)
-			hub.protoFunctions[msgNo] = fn
-		}
-	}
+// Group method    新建msgNo区间[min, max]的协议分组
+func (hub *WsSessionHub) Group(min, max uint32, middleware ...Middleware) *RouteGroup {
+	return hub.router.Group(min, max, middleware...)
+}
+
+// Router method    协议路由
+func (hub *WsSessionHub) Router() *Router {
+	return hub.router
 }
 
 // Exit method    注册
@@ -124,9 +146,12 @@ func (hub *WsSessionHub) GetSessionAll() (sessionList []*WsSession) {
 	return
 }
 
-// AddSession method    增加会话
+// AddSession method    增加会话, 集群模式下登记uid所在节点
 func (hub *WsSessionHub) AddSession(uid int32, s *WsSession) (actual *WsSession, loaded bool) {
 	actual, loaded = hub.sessionMap.LoadOrStore(uid, s)
+	if !loaded && hub.cluster != nil {
+		hub.cluster.register(uid)
+	}
 
 	return
 }
@@ -138,7 +163,28 @@ func (hub *WsSessionHub) AtSessionClose(fn func(hub *WsSession, i int32)) {
 
 // RemoveSession method    移除会话
 func (hub *WsSessionHub) RemoveSession(uid int32) {
-	hub.sessionMap.Delete(uid)
+	if _, loaded := hub.sessionMap.LoadAndDelete(uid); loaded && hub.cluster != nil {
+		hub.cluster.unregister(uid)
+	}
+}
+
+// SetCluster method    开启集群模式, 订阅总线并登记本节点已有的uid
+func (hub *WsSessionHub) SetCluster(cluster *Cluster) error {
+	if err := cluster.start(hub); err != nil {
+		return err
+	}
+
+	hub.cluster = cluster
+	hub.sessionMap.Range(func(uid int32, _ *WsSession) bool {
+		cluster.register(uid)
+		return true
+	})
+	return nil
+}
+
+// GetCluster method    获取集群模式, 未开启时为nil
+func (hub *WsSessionHub) GetCluster() *Cluster {
+	return hub.cluster
 }
 
 // SessionNum method    获取会话数量
@@ -156,11 +202,26 @@ func (hub *WsSessionHub) SetTimeoutWrite(timeout time.Duration) {
 	hub.timeoutWrite = timeout
 }
 
+// SetWriteQueue method    设置会话写队列上限与超限策略, 只对之后建立的会话生效
+func (hub *WsSessionHub) SetWriteQueue(maxLen, maxBytes int, policy string) {
+	hub.writeQueueLen = maxLen
+	hub.writeQueueBytes = maxBytes
+	hub.writeQueuePolicy = policy
+}
+
 // SetTimeoutRead method    设置读取超时时间
 func (hub *WsSessionHub) SetTimeoutRead(timeout time.Duration) {
 	hub.timeoutRead = timeout
 }
 
+// SetReadLimit method    设置单帧读取上限(字节), 超过时连接被关闭, 不大于0时使用EDefaultReadLimit, 只对之后建立的会话生效
+func (hub *WsSessionHub) SetReadLimit(limit int64) {
+	if limit <= 0 {
+		limit = EDefaultReadLimit
+	}
+	hub.readLimit = min(limit, int64(MaxPacketLen))
+}
+
 // SetMessage method    设置会话
 func (hub *WsSessionHub) SetMessage(message IMessage) {
 	hub.message = message
@@ -175,8 +236,19 @@ func (hub *WsSessionHub) GetSession(uid int32) *WsSession {
 	return nil
 }
 
-// PushMsg method    向会话推送
+// PushMsg method    向会话推送, 集群模式下uid不在本节点时转发给所在节点
 func (hub *WsSessionHub) PushMsg(uid int32, message IMessage) bool {
+	if hub.pushLocal(uid, message) {
+		return true
+	}
+	if hub.cluster != nil {
+		return hub.cluster.forward(uid, message)
+	}
+	return false
+}
+
+// pushLocal method    向本节点的会话推送
+func (hub *WsSessionHub) pushLocal(uid int32, message IMessage) bool {
 	session, ok := hub.sessionMap.Load(uid)
 	if !ok {
 		return false
@@ -193,17 +265,27 @@ func (hub *WsSessionHub) PushWork(uid int32, fn func()) bool {
 	return s.PushWork(fn)
 }
 
-// PushToAll method    向所有ws推送消息
+// PushToAll method    向所有ws推送消息, 集群模式下扇出到全部节点
 func (hub *WsSessionHub) PushToAll(message IMessage) {
-	var all []*WsSession
+	hub.pushToAllLocal(message)
+	if hub.cluster != nil {
+		hub.cluster.broadcast(message)
+	}
+}
+
+// pushToAllLocal method    向本节点所有ws推送消息, 每种编解码只编码一次
+func (hub *WsSessionHub) pushToAllLocal(message IMessage) {
+	frames := newFrameCache(message)
 	hub.sessionMap.Range(func(key int32, value *WsSession) bool {
-		all = append(all, value)
+		buf, err := frames.get(value)
+		if err != nil {
+			log.Println("push to all encode error", err)
+			return false
+		}
+
+		value.sendMsg(buf)
 		return true
 	})
-
-	for _, s := range all {
-		s.PushMsg(message)
-	}
 }
 
 func (hub *WsSessionHub) DoSomething(fn func(uid int32, session *WsSession) bool) {
@@ -211,3 +293,17 @@ func (hub *WsSessionHub) DoSomething(fn func(uid int32, session *WsSession) bool
 		return fn(key, value)
 	})
 }
+
+// Push method    按msgNo推送给uid, 对方不在线时返回false
+func (hub *WsSessionHub) Push(uid int32, msgNo uint32, body []byte) bool {
+	msg := &Message{MsgNo: msgNo, Body: body}
+	msg.SetLength()
+	return hub.PushMsg(uid, msg)
+}
+
+// PushAll method    按msgNo推送给全部已绑定uid的会话
+func (hub *WsSessionHub) PushAll(msgNo uint32, body []byte) {
+	msg := &Message{MsgNo: msgNo, Body: body}
+	msg.SetLength()
+	hub.PushToAll(msg)
+}
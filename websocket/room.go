@@ -0,0 +1,180 @@
+package websocket
+
+import (
+	"log"
+	"sort"
+	"sync"
+)
+
+// roomSet struct    房间成员, 房间内没有成员时自动删除
+type roomSet struct {
+	mu      sync.RWMutex
+	members map[string]map[int32]struct{} // 房间对应的uid
+	joined  map[int32]map[string]struct{} // uid加入的房间
+}
+
+// newRoomSet function    新建房间集合
+func newRoomSet() *roomSet {
+	return &roomSet{
+		members: make(map[string]map[int32]struct{}),
+		joined:  make(map[int32]map[string]struct{}),
+	}
+}
+
+func (r *roomSet) join(uid int32, room string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.members[room] == nil {
+		r.members[room] = make(map[int32]struct{})
+	}
+	r.members[room][uid] = struct{}{}
+
+	if r.joined[uid] == nil {
+		r.joined[uid] = make(map[string]struct{})
+	}
+	r.joined[uid][room] = struct{}{}
+}
+
+func (r *roomSet) leave(uid int32, room string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.leaveLocked(uid, room)
+}
+
+func (r *roomSet) leaveLocked(uid int32, room string) {
+	if members, ok := r.members[room]; ok {
+		delete(members, uid)
+		if len(members) == 0 {
+			delete(r.members, room)
+		}
+	}
+	if rooms, ok := r.joined[uid]; ok {
+		delete(rooms, room)
+		if len(rooms) == 0 {
+			delete(r.joined, uid)
+		}
+	}
+}
+
+func (r *roomSet) leaveAll(uid int32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for room := range r.joined[uid] {
+		r.leaveLocked(uid, room)
+	}
+}
+
+func (r *roomSet) memberList(room string) []int32 {
+	r.mu.RLock()
+	uids := make([]int32, 0, len(r.members[room]))
+	for uid := range r.members[room] {
+		uids = append(uids, uid)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+	return uids
+}
+
+func (r *roomSet) roomList(uid int32) []string {
+	r.mu.RLock()
+	rooms := make([]string, 0, len(r.joined[uid]))
+	for room := range r.joined[uid] {
+		rooms = append(rooms, room)
+	}
+	r.mu.RUnlock()
+
+	sort.Strings(rooms)
+	return rooms
+}
+
+// Join method    uid加入房间, uid不在本节点时返回false
+func (hub *WsSessionHub) Join(uid int32, room string) bool {
+	if _, ok := hub.sessionMap.Load(uid); !ok {
+		return false
+	}
+
+	hub.rooms.join(uid, room)
+	return true
+}
+
+// Leave method    uid离开房间
+func (hub *WsSessionHub) Leave(uid int32, room string) {
+	hub.rooms.leave(uid, room)
+}
+
+// LeaveAll method    uid离开全部房间
+func (hub *WsSessionHub) LeaveAll(uid int32) {
+	hub.rooms.leaveAll(uid)
+}
+
+// Members method    房间内本节点的uid, 按uid升序
+func (hub *WsSessionHub) Members(room string) []int32 {
+	return hub.rooms.memberList(room)
+}
+
+// Rooms method    uid加入的房间
+func (hub *WsSessionHub) Rooms(uid int32) []string {
+	return hub.rooms.roomList(uid)
+}
+
+// PushToRoom method    向房间推送, 每种编解码只编码一次, 集群模式下扇出到全部节点, 返回本节点送达的数量
+func (hub *WsSessionHub) PushToRoom(room string, message IMessage, exclude ...int32) int {
+	num := hub.pushToRoomLocal(room, message, exclude)
+	if hub.cluster != nil {
+		hub.cluster.room(room, message, exclude)
+	}
+	return num
+}
+
+// pushToRoomLocal method    向本节点的房间成员推送
+func (hub *WsSessionHub) pushToRoomLocal(room string, message IMessage, exclude []int32) int {
+	frames := newFrameCache(message)
+
+	var num int
+	for _, uid := range hub.rooms.memberList(room) {
+		if containsUid(exclude, uid) {
+			continue
+		}
+
+		session, ok := hub.sessionMap.Load(uid)
+		if !ok {
+			continue
+		}
+
+		buf, err := frames.get(session)
+		if err != nil {
+			log.Println("push to room encode error", room, err)
+			return num
+		}
+		if session.sendMsg(buf) {
+			num++
+		}
+	}
+	return num
+}
+
+// leaveRoomsAtClose method    会话关闭时离开全部房间, uid已被新会话占用时保留
+func (hub *WsSessionHub) leaveRoomsAtClose(session *WsSession, uid int32) {
+	if uid == 0 {
+		return
+	}
+	if current, ok := hub.sessionMap.Load(uid); ok && current != session {
+		return
+	}
+
+	hub.rooms.leaveAll(uid)
+}
+
+// containsUid function    uid是否在列表中
+func containsUid(uids []int32, uid int32) bool {
+	for _, item := range uids {
+		if item == uid {
+			return true
+		}
+	}
+	return false
+}
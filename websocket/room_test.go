@@ -0,0 +1,214 @@
+package websocket
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestRoomSet(t *testing.T) {
+	type op struct {
+		kind string // join, leave, leaveAll
+		uid  int32
+		room string
+	}
+	tests := []struct {
+		name    string
+		ops     []op
+		members map[string][]int32 // 房间对应的uid
+		rooms   map[int32][]string // uid加入的房间
+	}{
+		{
+			name:    "join",
+			ops:     []op{{"join", 2, "a"}, {"join", 1, "a"}, {"join", 1, "b"}},
+			members: map[string][]int32{"a": {1, 2}, "b": {1}},
+			rooms:   map[int32][]string{1: {"a", "b"}, 2: {"a"}},
+		},
+		{
+			name:    "join twice",
+			ops:     []op{{"join", 1, "a"}, {"join", 1, "a"}},
+			members: map[string][]int32{"a": {1}},
+			rooms:   map[int32][]string{1: {"a"}},
+		},
+		{
+			name:    "leave",
+			ops:     []op{{"join", 1, "a"}, {"join", 2, "a"}, {"join", 1, "b"}, {"leave", 1, "a"}},
+			members: map[string][]int32{"a": {2}, "b": {1}},
+			rooms:   map[int32][]string{1: {"b"}, 2: {"a"}},
+		},
+		{
+			name:    "leave unknown room",
+			ops:     []op{{"join", 1, "a"}, {"leave", 1, "b"}, {"leave", 2, "a"}},
+			members: map[string][]int32{"a": {1}},
+			rooms:   map[int32][]string{1: {"a"}},
+		},
+		{
+			name:    "leave all",
+			ops:     []op{{"join", 1, "a"}, {"join", 1, "b"}, {"join", 2, "b"}, {"leaveAll", 1, ""}},
+			members: map[string][]int32{"b": {2}},
+			rooms:   map[int32][]string{2: {"b"}},
+		},
+		{
+			name:    "empty rooms are removed",
+			ops:     []op{{"join", 1, "a"}, {"leave", 1, "a"}},
+			members: map[string][]int32{},
+			rooms:   map[int32][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRoomSet()
+			for _, o := range tt.ops {
+				switch o.kind {
+				case "join":
+					r.join(o.uid, o.room)
+				case "leave":
+					r.leave(o.uid, o.room)
+				case "leaveAll":
+					r.leaveAll(o.uid)
+				}
+			}
+
+			if len(r.members) != len(tt.members) || len(r.joined) != len(tt.rooms) {
+				t.Fatalf("rooms = %d, uids = %d, want %d, %d", len(r.members), len(r.joined), len(tt.members), len(tt.rooms))
+			}
+			for room, want := range tt.members {
+				if got := r.memberList(room); !reflect.DeepEqual(got, want) {
+					t.Errorf("members(%q) = %v, want %v", room, got, want)
+				}
+			}
+			for uid, want := range tt.rooms {
+				if got := r.roomList(uid); !reflect.DeepEqual(got, want) {
+					t.Errorf("rooms(%d) = %v, want %v", uid, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHubJoin(t *testing.T) {
+	server := startTestServer(t, NewWsServer(""))
+	server.dial(t, 1)
+
+	if !server.Join(1, "lobby") {
+		t.Error("join of a local uid failed")
+	}
+	if server.Join(2, "lobby") {
+		t.Error("join of an offline uid succeeded")
+	}
+	if got := server.Members("lobby"); !reflect.DeepEqual(got, []int32{1}) {
+		t.Errorf("members = %v, want [1]", got)
+	}
+
+	server.Leave(1, "lobby")
+	if got := server.Rooms(1); len(got) != 0 {
+		t.Errorf("rooms after leave = %v, want none", got)
+	}
+}
+
+func TestLeaveRoomsAtClose(t *testing.T) {
+	server := startTestServer(t, NewWsServer(""))
+	_, old := server.dial(t, 5)
+	server.Join(5, "lobby")
+
+	// 旧会话关闭前uid已被新会话占用, 房间保留给新会话
+	server.RemoveSession(5)
+	conn, current := server.dial(t, 0)
+	if !current.SetUid(5) {
+		t.Fatal("takeover of uid 5 failed")
+	}
+	server.leaveRoomsAtClose(old, 5)
+	if got := server.Rooms(5); !reflect.DeepEqual(got, []string{"lobby"}) {
+		t.Fatalf("rooms after old session closed = %v, want [lobby]", got)
+	}
+
+	// 未绑定uid的会话关闭时不处理
+	server.leaveRoomsAtClose(old, 0)
+	if got := server.Rooms(5); len(got) != 1 {
+		t.Fatalf("rooms after unbound session closed = %v, want [lobby]", got)
+	}
+
+	// 当前会话断开后离开全部房间
+	conn.Close()
+	deadline := time.Now().Add(time.Second)
+	for len(server.Rooms(5)) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := server.Rooms(5); len(got) != 0 {
+		t.Errorf("rooms after current session closed = %v, want none", got)
+	}
+}
+
+// countingCodec struct    统计编码次数的编解码
+type countingCodec struct {
+	JSONCodec
+	encodes *atomic.Int32
+}
+
+func (countingCodec) Name() string {
+	return "test.counting"
+}
+
+func (c countingCodec) Encode(msg IMessage) ([]byte, error) {
+	c.encodes.Add(1)
+	return c.JSONCodec.Encode(msg)
+}
+
+func TestPushToRoomEncodesOncePerCodec(t *testing.T) {
+	counting := countingCodec{encodes: &atomic.Int32{}}
+	server := NewWsServer("")
+	server.SetCodecs(BinaryCodec{}, JSONCodec{}, ProtobufCodec{}, counting)
+	ts := startTestServer(t, server)
+
+	clients := []struct {
+		uid      int32
+		protocol string
+		codec    Codec
+	}{
+		{uid: 1, codec: BinaryCodec{}},
+		{uid: 2, protocol: ECodecJSON, codec: JSONCodec{}},
+		{uid: 3, protocol: ECodecProtobuf, codec: ProtobufCodec{}},
+		{uid: 4, protocol: counting.Name(), codec: counting},
+		{uid: 5, protocol: counting.Name(), codec: counting},
+		{uid: 6, protocol: counting.Name(), codec: counting},
+	}
+
+	conns := make([]*websocket.Conn, 0, len(clients))
+	for _, client := range clients {
+		var protocols []string
+		if client.protocol != "" {
+			protocols = append(protocols, client.protocol)
+		}
+		conn, _ := ts.dial(t, client.uid, protocols...)
+		conns = append(conns, conn)
+		ts.Join(client.uid, "lobby")
+	}
+
+	if num := ts.PushToRoom("lobby", &Message{MsgNo: 20, Body: []byte("room")}, 6); num != len(clients)-1 {
+		t.Fatalf("delivered = %d, want %d", num, len(clients)-1)
+	}
+	if n := counting.encodes.Load(); n != 1 {
+		t.Errorf("counting codec encoded %d times, want 1", n)
+	}
+	for i, client := range clients[:len(clients)-1] {
+		msg := readMessage(t, conns[i], client.codec)
+		if msg.GetMsgNo() != 20 || string(msg.GetBody()) != "room" {
+			t.Errorf("uid %d got %d %q, want 20 \"room\"", client.uid, msg.GetMsgNo(), msg.GetBody())
+		}
+	}
+
+	cache := newFrameCache(&Message{MsgNo: 21, Body: []byte("cache")})
+	for _, client := range clients {
+		session := ts.GetSession(client.uid)
+		if _, err := cache.get(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(cache.frames) != 4 {
+		t.Errorf("cached frames = %d, want one per codec (4)", len(cache.frames))
+	}
+}
@@ -0,0 +1,145 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrDuplicateMsgNo 重复注册同一个msgNo
+var ErrDuplicateMsgNo = errors.New("websocket: duplicate msgNo")
+
+// route struct    已注册的协议
+type route struct {
+	handler    ProtoFunc
+	middleware []Middleware // 只作用于该协议的中间件
+}
+
+// Router struct    协议路由, 中间件在注册后追加也会生效
+//
+// 执行顺序为 全局中间件 -> 覆盖msgNo的分组中间件(按分组创建顺序) -> 协议自身的中间件 -> 回调, 先添加的先执行
+type Router struct {
+	mu         sync.Mutex
+	routes     map[uint32]*route
+	middleware []Middleware
+	groups     []*RouteGroup
+	compiled   atomic.Pointer[map[uint32]ProtoFunc] // 组合好中间件的回调, 每次变更后重建
+}
+
+// NewRouter function    新建协议路由
+func NewRouter() *Router {
+	r := &Router{routes: make(map[uint32]*route)}
+	r.compile()
+	return r
+}
+
+// Use method    添加全局中间件
+func (r *Router) Use(middleware ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.middleware = append(r.middleware, middleware...)
+	r.compile()
+}
+
+// Register method    注册协议回调, msgNo已注册时返回ErrDuplicateMsgNo
+func (r *Router) Register(msgNo uint32, fn ProtoFunc, middleware ...Middleware) error {
+	if fn == nil {
+		return fmt.Errorf("websocket: nil handler for msgNo %d", msgNo)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.routes[msgNo]; ok {
+		return fmt.Errorf("%w %d", ErrDuplicateMsgNo, msgNo)
+	}
+	r.routes[msgNo] = &route{handler: fn, middleware: middleware}
+	r.compile()
+	return nil
+}
+
+// Group method    新建msgNo区间[min, max]的分组, 分组中间件作用于区间内的全部协议
+func (r *Router) Group(min, max uint32, middleware ...Middleware) *RouteGroup {
+	group := &RouteGroup{router: r, min: min, max: max, middleware: middleware}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.groups = append(r.groups, group)
+	r.compile()
+	return group
+}
+
+// Routes method    已注册的msgNo, 升序
+func (r *Router) Routes() []uint32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msgNos := make([]uint32, 0, len(r.routes))
+	for msgNo := range r.routes {
+		msgNos = append(msgNos, msgNo)
+	}
+	sort.Slice(msgNos, func(i, j int) bool { return msgNos[i] < msgNos[j] })
+	return msgNos
+}
+
+// lookup method    查找组合好中间件的回调
+func (r *Router) lookup(msgNo uint32) (ProtoFunc, bool) {
+	fn, ok := (*r.compiled.Load())[msgNo]
+	return fn, ok
+}
+
+// compile method    重新组合全部协议的中间件, 调用方持有锁
+func (r *Router) compile() {
+	compiled := make(map[uint32]ProtoFunc, len(r.routes))
+	for msgNo, rt := range r.routes {
+		chain := make([]Middleware, 0, len(r.middleware)+len(rt.middleware))
+		chain = append(chain, r.middleware...)
+		for _, group := range r.groups {
+			if group.contains(msgNo) {
+				chain = append(chain, group.middleware...)
+			}
+		}
+		chain = append(chain, rt.middleware...)
+
+		fn := rt.handler
+		for idx := len(chain) - 1; idx >= 0; idx-- {
+			fn = chain[idx](fn)
+		}
+		compiled[msgNo] = fn
+	}
+	r.compiled.Store(&compiled)
+}
+
+// RouteGroup struct    按msgNo区间划分的协议分组
+type RouteGroup struct {
+	router     *Router
+	min        uint32
+	max        uint32
+	middleware []Middleware
+}
+
+// Use method    添加分组中间件
+func (g *RouteGroup) Use(middleware ...Middleware) {
+	g.router.mu.Lock()
+	defer g.router.mu.Unlock()
+
+	g.middleware = append(g.middleware, middleware...)
+	g.router.compile()
+}
+
+// Register method    在分组中注册协议回调, msgNo不在分组区间内时返回错误
+func (g *RouteGroup) Register(msgNo uint32, fn ProtoFunc, middleware ...Middleware) error {
+	if !g.contains(msgNo) {
+		return fmt.Errorf("websocket: msgNo %d outside group %d-%d", msgNo, g.min, g.max)
+	}
+	return g.router.Register(msgNo, fn, middleware...)
+}
+
+// contains method    msgNo是否在分组区间内
+func (g *RouteGroup) contains(msgNo uint32) bool {
+	return msgNo >= g.min && msgNo <= g.max
+}
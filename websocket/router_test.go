@@ -0,0 +1,101 @@
+package websocket
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// traceMiddleware function    记录执行顺序的中间件
+func traceMiddleware(trace *[]string, name string) Middleware {
+	return func(next ProtoFunc) ProtoFunc {
+		return func(session *WsSession, message IMessage) []byte {
+			*trace = append(*trace, name)
+			return next(session, message)
+		}
+	}
+}
+
+func TestRouterCompileOrder(t *testing.T) {
+	var trace []string
+	handler := func(name string) ProtoFunc {
+		return func(*WsSession, IMessage) []byte {
+			trace = append(trace, name)
+			return nil
+		}
+	}
+
+	router := NewRouter()
+	router.Use(traceMiddleware(&trace, "global1"))
+	low := router.Group(1, 10, traceMiddleware(&trace, "low"))
+	all := router.Group(1, 100, traceMiddleware(&trace, "all"))
+	if err := low.Register(5, handler("h5"), traceMiddleware(&trace, "route5")); err != nil {
+		t.Fatal(err)
+	}
+	if err := all.Register(50, handler("h50")); err != nil {
+		t.Fatal(err)
+	}
+	if err := router.Register(200, handler("h200")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 注册之后追加的中间件同样生效
+	router.Use(traceMiddleware(&trace, "global2"))
+	low.Use(traceMiddleware(&trace, "low2"))
+
+	tests := []struct {
+		msgNo uint32
+		want  []string
+	}{
+		{5, []string{"global1", "global2", "low", "low2", "all", "route5", "h5"}},
+		{50, []string{"global1", "global2", "all", "h50"}},
+		{200, []string{"global1", "global2", "h200"}},
+	}
+
+	for _, tt := range tests {
+		trace = nil
+		fn, ok := router.lookup(tt.msgNo)
+		if !ok {
+			t.Fatalf("msgNo %d not found", tt.msgNo)
+		}
+		fn(nil, &Message{MsgNo: tt.msgNo})
+		if !reflect.DeepEqual(trace, tt.want) {
+			t.Errorf("msgNo %d order = %v, want %v", tt.msgNo, trace, tt.want)
+		}
+	}
+}
+
+func TestRouterRegisterErrors(t *testing.T) {
+	router := NewRouter()
+	noop := func(*WsSession, IMessage) []byte { return nil }
+	if err := router.Register(1, noop); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		register func() error
+		dup      bool
+	}{
+		{"duplicate msgNo", func() error { return router.Register(1, noop) }, true},
+		{"nil handler", func() error { return router.Register(2, nil) }, false},
+		{"outside group", func() error { return router.Group(10, 20).Register(21, noop) }, false},
+		{"duplicate through group", func() error { return router.Group(1, 5).Register(1, noop) }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.register()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if errors.Is(err, ErrDuplicateMsgNo) != tt.dup {
+				t.Errorf("errors.Is(ErrDuplicateMsgNo) = %v, want %v: %v", !tt.dup, tt.dup, err)
+			}
+		})
+	}
+
+	if got := router.Routes(); !reflect.DeepEqual(got, []uint32{1}) {
+		t.Errorf("Routes = %v, want [1]", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -20,8 +21,13 @@ func GetServer() *WsServer {
 // WsServer 服务器结构
 type WsServer struct {
 	httpServer    *http.Server // HTTP 服务器
+	handler       *WsHandler   // 握手处理
 	stopSignal    bool         // 停止信号
+	listening     atomic.Bool  // Start已开始监听
+	closeOnce     sync.Once    // Close只执行一次
 	exitFunctions []func()     // 退出回调
+	auth          *WsAuth      // 握手鉴权
+	allowOrigins  []string     // origin白名单
 	WsSessionHub               // ws会话管理器
 }
 
@@ -30,17 +36,18 @@ func NewWsServer(addr string) *WsServer {
 	server := &WsServer{}
 
 	server.WsSessionHub.Init() // 初始化会话管理器
-	server.httpServer = &http.Server{
-		Addr: addr,
-		Handler: &WsHandler{
-			upgrade: websocket.Upgrader{
-				HandshakeTimeout: 10 * time.Second,
-				CheckOrigin: func(r *http.Request) bool {
-					return true
-				},
-			},
-			server: server,
+	server.SetCodecs(BinaryCodec{}, JSONCodec{}, ProtobufCodec{})
+	server.markPush = true
+	server.handler = &WsHandler{
+		upgrade: websocket.Upgrader{
+			HandshakeTimeout: 10 * time.Second,
+			CheckOrigin:      server.checkOrigin,
 		},
+		server: server,
+	}
+	server.httpServer = &http.Server{
+		Addr:    addr,
+		Handler: server.handler,
 	}
 
 	server.stopSignal = false
@@ -48,10 +55,11 @@ func NewWsServer(addr string) *WsServer {
 	return server
 }
 
-// Start method    开启ws服务器
+// Start method    开启ws服务器, 监听结束后关闭全部会话并执行退出回调
 func (s *WsServer) Start() {
 	var err error
 
+	s.listening.Store(true)
 	if s.certFile != "" && s.keyFile != "" {
 		if err = s.httpServer.ListenAndServeTLS(s.certFile, s.keyFile); err != nil {
 			log.Printf("https server close, err = %v", err)
@@ -63,25 +71,29 @@ func (s *WsServer) Start() {
 		}
 	}
 
-	sessions := make([]*WsSession, 1)
-
-	s.sessions.Range(func(key *WsSession, value bool) bool {
-		sessions = append(sessions, key)
-		return true
-	})
+	s.Close()
+}
 
-	// 清空已经有的连接
-	for _, wsSession := range sessions {
-		if wsSession != nil {
+// Close method    关闭全部会话并执行AtClose登记的退出回调, 只执行一次
+//
+// 单独监听时由Start在监听结束后调用, 挂载到gin时在gin服务Shutdown时调用
+func (s *WsServer) Close() {
+	s.closeOnce.Do(func() {
+		sessions := make([]*WsSession, 0)
+		s.sessions.Range(func(key *WsSession, value bool) bool {
+			sessions = append(sessions, key)
+			return true
+		})
+
+		// 清空已经有的连接
+		for _, wsSession := range sessions {
 			wsSession.close(true)
 		}
-	}
-
-	for _, fn := range s.exitFunctions {
-		fn()
-	}
 
-	return
+		for _, fn := range s.exitFunctions {
+			fn()
+		}
+	})
 }
 
 // SetTimeoutCloseRead method    设置关闭等待时间
@@ -100,13 +112,25 @@ func (s *WsServer) SessionNum() int32 {
 	return atomic.LoadInt32(&s.sessionNum)
 }
 
-// Stop method    停止服务
+// Stop method    停止服务, 单独监听时先停止监听, 之后关闭全部会话并执行退出回调
 func (s *WsServer) Stop() {
 	s.stopSignal = true
-	err := s.httpServer.Shutdown(context.TODO())
-	if err != nil {
-		log.Println("server stop error", err.Error())
+	if s.listening.Load() {
+		if err := s.httpServer.Shutdown(context.TODO()); err != nil {
+			log.Println("server stop error", err.Error())
+		}
 	}
+	s.Close()
+}
+
+// SetCluster method    开启集群模式, 服务关闭时停止集群并注销本节点的uid
+func (s *WsServer) SetCluster(cluster *Cluster) error {
+	if err := s.WsSessionHub.SetCluster(cluster); err != nil {
+		return err
+	}
+
+	s.AtClose(cluster.Stop)
+	return nil
 }
 
 // AtClose method    添加关闭服务回调函数
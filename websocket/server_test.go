@@ -0,0 +1,75 @@
+package websocket
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func TestStopMountedServer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := NewWsServer("")
+	var exits int32
+	server.AtClose(func() { atomic.AddInt32(&exits, 1) })
+
+	engine := gin.New()
+	engine.GET("/ws", server.GinHandler())
+	ts := httptest.NewServer(engine)
+	defer ts.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for server.SessionNum() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 没有单独监听, Stop不能依赖Start关闭会话
+	server.Stop()
+	server.Stop()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err = conn.ReadMessage(); err == nil {
+		t.Error("session still open after Stop")
+	}
+	if n := atomic.LoadInt32(&exits); n != 1 {
+		t.Errorf("exit functions run %d times, want 1", n)
+	}
+}
+
+func TestStopListeningServer(t *testing.T) {
+	server := NewWsServer("127.0.0.1:0")
+	var exits int32
+	server.AtClose(func() { atomic.AddInt32(&exits, 1) })
+
+	done := make(chan struct{})
+	go func() {
+		server.Start()
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !server.listening.Load() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	server.Stop()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Start did not return after Stop")
+	}
+	if n := atomic.LoadInt32(&exits); n != 1 {
+		t.Errorf("exit functions run %d times, want 1", n)
+	}
+}
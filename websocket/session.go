@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"context"
 	"errors"
 	"log"
 	"net"
@@ -23,36 +24,78 @@ type WsSession struct {
 	uid          int32
 	state        int32
 	conn         *websocket.Conn // ws连接句柄
+	batch        *batchConn      // 底层连接可以批量写出时不为nil
 	writeQueue   *MessageQueue   // 消息队列
 	msg          *Message        //消息
 	workQueue    *WorkQueue      // 工作队列
 	hub          *WsSessionHub   //会话管理
 	Request      *http.Request   // http请求句柄
 	wg           sync.WaitGroup
-	timeoutRead  time.Duration // 读超时
-	timeoutWrite time.Duration // 写超时
+	timeoutRead  time.Duration                   // 读超时
+	timeoutWrite time.Duration                   // 写超时
+	codec        Codec                           // 握手时协商的编解码, 为nil时使用会话管理器的包结构
+	peerSeq      int32                           // 对端发送过带序列号的帧, 可以识别推送标记
+	missedPongs  int32                           // 连续未响应的ping次数
+	slowClosed   int32                           // 已因写队列堆积断开
+	ctx          context.Context                 // 会话关闭时取消
+	cancel       context.CancelFunc              // 取消ctx
+	callCtx      atomic.Pointer[context.Context] // 正在执行的回调的context
+	tasks        []*sessionTask                  // 等待协程池执行的任务
+	taskMu       sync.Mutex                      // 任务队列锁
+	taskRunning  bool                            // 任务队列是否已提交到协程池
 	Context      sync.Map
 }
 
-// NewWsSession function    新建ws会话管理
+// NewWsSession function    新建ws会话管理并开始读写
 func NewWsSession(hub *WsSessionHub, conn *websocket.Conn, req *http.Request) *WsSession {
+	ws := newWsSession(hub, conn, req)
+	ws.start()
+	return ws
+}
+
+// newWsSession function    新建ws会话管理, 调用start之前不会读取消息, 可以先完成uid绑定
+func newWsSession(hub *WsSessionHub, conn *websocket.Conn, req *http.Request) *WsSession {
 	ws := &WsSession{}
 
 	ws.conn = conn
+	ws.batch, _ = conn.NetConn().(*batchConn)
 	ws.Request = req
+	if req != nil {
+		// 升级完成后请求的context会被取消, 只保留其中的值
+		ws.ctx, ws.cancel = context.WithCancel(context.WithoutCancel(req.Context()))
+	} else {
+		ws.ctx, ws.cancel = context.WithCancel(context.Background())
+	}
 	ws.writeQueue = NewMessageQueue()
+	ws.writeQueue.SetLimit(hub.writeQueueLen, hub.writeQueueBytes, hub.writeQueuePolicy)
 	ws.workQueue = NewWorkQueue()
 	ws.wg.Add(2)
 	ws.timeoutRead = 5 * time.Minute
 	ws.timeoutWrite = 5 * time.Minute
+	if hub.timeoutRead > 0 {
+		ws.timeoutRead = hub.timeoutRead
+	}
+	if hub.timeoutWrite > 0 {
+		ws.timeoutWrite = hub.timeoutWrite
+	}
 	ws.hub = hub
+	ws.codec = hub.getCodec(conn.Subprotocol())
+	if hub.readLimit > 0 {
+		conn.SetReadLimit(hub.readLimit)
+	} else {
+		conn.SetReadLimit(EDefaultReadLimit)
+	}
 
 	hub.sessions.Store(ws, true)
 	atomic.AddInt32(&hub.sessionNum, 1)
 
-	coder := hub.message
+	log.Println("new session: ", ws.ClientIP())
+	return ws
+}
+
+// start method    启动写协程、ping协程与读协程
+func (ws *WsSession) start() {
 	remoteAddr := ws.ClientIP()
-	log.Println("new session: ", remoteAddr)
 
 	// 循环从写队列中取出然后发送给ws链接
 	go SafeGoRecoverWarpFunc(func() {
@@ -64,11 +107,11 @@ func NewWsSession(hub *WsSessionHub, conn *websocket.Conn, req *http.Request) *W
 			writeList := writeList[0:0]
 			exit := ws.writeQueue.Pick(&writeList)
 
-			for _, msg := range writeList {
-				err = ws.conn.WriteMessage(websocket.BinaryMessage, msg)
-				if err != nil {
-					break LabelWriteThread
-				}
+			// 一次取出的消息共用一个写超时
+			ws.conn.SetWriteDeadline(time.Now().Add(ws.timeoutWrite))
+			if err = ws.writeBatch(writeList); err != nil {
+				log.Println("send thread write error", remoteAddr, err)
+				break LabelWriteThread
 			}
 
 			if exit {
@@ -76,8 +119,16 @@ func NewWsSession(hub *WsSessionHub, conn *websocket.Conn, req *http.Request) *W
 			}
 		}
 
+		// 写完剩余消息后关闭连接, 读协程随之退出
+		if atomic.LoadInt32(&ws.slowClosed) == 1 {
+			closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "slow consumer")
+			ws.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+		}
+		ws.conn.Close()
 	})()
 
+	ws.keepalive()
+
 	go SafeGoRecoverWarpFunc(func() {
 		// 处理正在进行中的工作
 		for {
@@ -87,17 +138,20 @@ func NewWsSession(hub *WsSessionHub, conn *websocket.Conn, req *http.Request) *W
 			}
 
 			readBeginTime := time.Now()
-			conn.SetReadDeadline(time.Now().Add(ws.timeoutRead))
+			ws.conn.SetReadDeadline(time.Now().Add(ws.timeoutRead))
 
-			msg, err := coder.Decode(conn)
+			msg, err := ws.read()
 			if err != nil {
 				var netErr *net.OpError
-				if errors.As(err, &netErr) && netErr.Timeout() {
+				if errors.Is(err, ErrInvalidFrame) {
+					log.Println("recv thread invalid frame", remoteAddr, err)
+					continue
+				} else if errors.As(err, &netErr) && netErr.Timeout() {
+					// 读超时后连接不可再读, 空闲超时或被踢出的会话直接结束
 					if time.Now().Sub(readBeginTime) >= ws.timeoutRead {
-						log.Println("recv thread timeout", remoteAddr, err)
-						// todo 超时错误
-						continue
+						log.Println("recv thread idle timeout", remoteAddr, err)
 					}
+					break
 				} else {
 					// TODO 其他错误解决
 					break
@@ -106,39 +160,15 @@ func NewWsSession(hub *WsSessionHub, conn *websocket.Conn, req *http.Request) *W
 				log.Println("recv thread msg is nil", err)
 			} else {
 				// 收到消息
-				fn, ok := ws.hub.protoFunctions[msg.GetMsgNo()]
-				if ok {
-					SafeGoRecoverWarpFunc(func() {
-						//log.Println("handler begin: ", remoteAddr)
-
-						outMsg := &Message{
-							MsgNo: msg.GetMsgNo(),
-							Body:  fn(ws, msg),
-						}
-						//log.Println("handler end: ", remoteAddr)
-						outMsg.SetLength()
-
-						if outMsg.Body != nil {
-							msgStr, err := coder.Encode(outMsg)
-							if err != nil {
-								log.Println("proto handler", remoteAddr, err)
-							} else {
-								// 将协议发给对应的回调
-								ws.sendMsg(msgStr)
-							}
-						}
-
-						if ws.msg != nil {
-							msgStr, err := coder.Encode(ws.msg)
-							if err != nil {
-								log.Println("post msg: ", remoteAddr, err)
-							} else {
-								ws.sendMsg(msgStr)
-							}
-							ws.msg = nil
-						}
-					})()
-
+				ws.alive()
+				fn, ok := ws.hub.router.lookup(msg.GetMsgNo())
+				if !ok && ws.hub.heartbeatMsgNo != 0 && msg.GetMsgNo() == ws.hub.heartbeatMsgNo {
+					ws.heartbeat(msg)
+				} else if ok {
+					// 没有协程池时在读协程中执行, 否则按顺序交给协程池
+					ws.dispatch(func() {
+						ws.handle(fn, msg)
+					}, ws.hub.limitOf(msg.GetMsgNo()))
 				} else {
 					if ws.hub.UnregisteredCallback == nil {
 						log.Println("unkonw msg no", remoteAddr, msg.GetMsgNo())
@@ -153,14 +183,22 @@ func NewWsSession(hub *WsSessionHub, conn *websocket.Conn, req *http.Request) *W
 			// 从工作队列里面拿任务然后工作
 			funcList := ws.workQueue.Dump()
 			for _, work := range funcList {
-				work()
+				if work != nil {
+					work()
+				}
 			}
 		}
 
+		// 连接断开时从会话管理器移除, 集群模式下同时注销uid
+		ws.close(false)
+		ws.writeQueue.Add(nil)
+
 		// 处理没有完成的工作
 		funcList := ws.workQueue.Dump()
 		for _, work := range funcList {
-			work()
+			if work != nil {
+				work()
+			}
 		}
 
 		// 处理会话退出的任务
@@ -168,8 +206,95 @@ func NewWsSession(hub *WsSessionHub, conn *websocket.Conn, req *http.Request) *W
 			fn(ws, ws.GetUid())
 		}
 	})()
+}
 
-	return ws
+// read method    读取一帧并解码
+func (s *WsSession) read() (IMessage, error) {
+	if s.codec == nil {
+		return s.hub.message.Decode(s.conn)
+	}
+
+	frameType, data, err := s.conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	return s.codec.Decode(frameType, data)
+}
+
+// writeBatch method    写出一次取出的消息, 底层连接是batchConn时多帧合并成一次写出
+func (s *WsSession) writeBatch(writeList [][]byte) error {
+	if s.batch == nil {
+		for _, msg := range writeList {
+			if err := s.write(msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	s.batch.begin()
+	for _, msg := range writeList {
+		if err := s.write(msg); err != nil {
+			s.batch.flush()
+			return err
+		}
+	}
+	return s.batch.flush()
+}
+
+// write method    通过NextWriter写入一帧, 每条消息对应一帧
+func (s *WsSession) write(msg []byte) error {
+	w, err := s.conn.NextWriter(s.frameType())
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// encode method    按会话的编解码编码
+func (s *WsSession) encode(message IMessage) ([]byte, error) {
+	if s.codec == nil {
+		return s.hub.message.Encode(message)
+	}
+	return s.codec.Encode(message)
+}
+
+// pushFlagged method    主动推送是否附加推送标记, 只对能识别标记的对端附加
+func (s *WsSession) pushFlagged() bool {
+	if !s.hub.markPush {
+		return false
+	}
+	if s.codec != nil && s.codec.Name() != ECodecBinary {
+		return true
+	}
+	return atomic.LoadInt32(&s.peerSeq) == 1
+}
+
+// encodePush method    编码主动推送的消息
+func (s *WsSession) encodePush(message IMessage) ([]byte, error) {
+	if s.pushFlagged() {
+		return s.encode(pushMessage{message})
+	}
+	return s.encode(message)
+}
+
+// frameType method    发送使用的帧类型
+func (s *WsSession) frameType() int {
+	if s.codec == nil {
+		return websocket.BinaryMessage
+	}
+	return s.codec.FrameType()
+}
+
+// Codec method    握手时协商的子协议, 未协商时为空
+func (s *WsSession) Codec() string {
+	if s.codec == nil {
+		return ""
+	}
+	return s.codec.Name()
 }
 
 // GetTimeoutRead method    获取读取超时时间
@@ -200,10 +325,11 @@ func (s *WsSession) SetReadDeadline(t time.Time) error {
 // close method    关闭会话
 func (s *WsSession) close(wait bool) bool {
 	if atomic.CompareAndSwapInt32(&s.state, sessionRunning, sessionStop) {
+		s.cancel()
 		s.workQueue.Add(nil)
 		s.hub.sessions.Delete(s)
-		if s.uid != 0 {
-			s.hub.RemoveSession(s.uid)
+		if uid := s.GetUid(); uid != 0 {
+			s.hub.RemoveSession(uid)
 		}
 
 		s.conn.SetReadDeadline(time.Now().Add(s.hub.timeoutCloseRead))
@@ -270,25 +396,52 @@ func (s *WsSession) sendMsg(buf []byte) bool {
 	}()
 
 	if atomic.LoadInt32(&s.state) == sessionRunning {
-		s.writeQueue.Add(buf)
-		return true
+		if s.writeQueue.Add(buf) {
+			return true
+		}
+		if s.hub.writeQueuePolicy == EQueueDisconnect {
+			s.disconnectSlow()
+		}
+		return false
 	} else {
 		return false
 	}
 }
 
+// disconnectSlow method    写队列堆积超过上限, 丢弃未发送的消息并以ClosePolicyViolation断开
+func (s *WsSession) disconnectSlow() {
+	if !atomic.CompareAndSwapInt32(&s.slowClosed, 0, 1) {
+		return
+	}
+
+	log.Println("slow consumer, session disconnected", s.ClientIP())
+	s.close(false)
+	s.writeQueue.Reset()
+	s.writeQueue.Add(nil)
+}
+
+// QueueDepth method    写队列中等待发送的消息数与字节数
+func (s *WsSession) QueueDepth() (messages, bytes int) {
+	return s.writeQueue.Depth()
+}
+
+// QueueDropped method    写队列累计丢弃的消息数
+func (s *WsSession) QueueDropped() uint64 {
+	return s.writeQueue.Dropped()
+}
+
 // GetUid method    获取uid
 func (s *WsSession) GetUid() int32 {
-	return s.uid
+	return atomic.LoadInt32(&s.uid)
 }
 
 // SetUid method    设置uid
 func (s *WsSession) SetUid(uid int32) bool {
 	_, loaded := s.hub.AddSession(uid, s)
 	if !loaded {
-		s.uid = uid
+		atomic.StoreInt32(&s.uid, uid)
 		if s.Dead() {
-			s.uid = 0
+			atomic.StoreInt32(&s.uid, 0)
 			s.hub.RemoveSession(uid)
 			return false
 		}
@@ -302,7 +455,7 @@ func (s *WsSession) SetUid(uid int32) bool {
 func (s *WsSession) SetUidSafe(uid int32) bool {
 	_, loaded := s.hub.AddSession(uid, s)
 	if !loaded {
-		atomic.StoreInt32(&s.uid, 0)
+		atomic.StoreInt32(&s.uid, uid)
 		if s.Dead() {
 			atomic.StoreInt32(&s.uid, 0)
 			s.hub.RemoveSession(uid)
@@ -321,17 +474,21 @@ func (s *WsSession) GetUidSafe() int32 {
 
 // PushMsg method    将消息编码字节后发送
 func (s *WsSession) PushMsg(message IMessage) bool {
-	msgStr, err := s.hub.message.Encode(message)
+	msgStr, err := s.encodePush(message)
 	if err != nil {
 		return false
 	}
 	return s.sendMsg(msgStr)
 }
 
-// PushWork method    将任务函数提交到工作队列
+// PushWork method    将任务函数提交到工作队列, 使用协程池时与协议回调按顺序执行
 func (s *WsSession) PushWork(fn func()) bool {
 	if atomic.LoadInt32(&s.state) == sessionRunning {
-		s.workQueue.Add(SafeGoRecoverWarpFunc(fn))
+		if s.hub.pool != nil {
+			s.dispatch(fn, nil)
+		} else {
+			s.workQueue.Add(SafeGoRecoverWarpFunc(fn))
+		}
 		return true
 	}
 	return false
@@ -352,8 +509,8 @@ func (s *WsSession) waitAndClose() {
 		s.wg.Wait()
 		atomic.AddInt32(&s.hub.sessionNum, -1)
 		s.hub.sessions.Delete(s)
-		if s.uid != 0 {
-			s.hub.RemoveSession(s.uid)
+		if uid := s.GetUid(); uid != 0 {
+			s.hub.RemoveSession(uid)
 		}
 
 		s.workQueue.Reset()